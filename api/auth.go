@@ -54,8 +54,8 @@ func Login(ctx context.Context, project string, email string, password string) (
 	}(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		runtime.LogErrorf(ctx, "failed to login to %s, status code: %d, error: %s", url, resp.StatusCode, err.Error())
-		return ctx, fmt.Errorf("failed to login to %s, status code: %d, error: %s", url, resp.StatusCode, err)
+		runtime.LogErrorf(ctx, "failed to login to %s, status code: %d", url, resp.StatusCode)
+		return ctx, fmt.Errorf("failed to login to %s, status code: %d", url, resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
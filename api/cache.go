@@ -0,0 +1,316 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"games.launch.launcher/cache"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedResponse is an API response stored in the response cache.
+type cachedResponse struct {
+	Body    json.RawMessage `json:"body"`    // the response body
+	ETag    string          `json:"eTag"`    // the ETag of the response used to revalidate it
+	Expires time.Time       `json:"expires"` // the time the response becomes stale and has to be revalidated
+}
+
+// responseCache keeps the API responses in memory and optionally on disk.
+type responseCache struct {
+	mu     sync.RWMutex
+	memory map[string]*cachedResponse
+	disk   *cache.Store
+}
+
+// call is an in-flight request shared by the concurrent identical requests.
+type call struct {
+	done chan struct{} // closed once the request completes
+	body []byte
+	etag string
+	err  error
+}
+
+// detachedContext keeps the values of the parent context, the wails logger in particular, but is never cancelled, so the request
+// shared by several callers is not cancelled with the context of the one which has started it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+var ErrorRequestAborted = errors.New("shared request aborted")
+
+// requestGroup deduplicates the concurrent identical requests, so only one of them reaches the API and the rest wait for its result.
+type requestGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+var responses = &responseCache{memory: make(map[string]*cachedResponse)}
+
+var requests = &requestGroup{calls: make(map[string]*call)}
+
+// offlineHandler is notified whether the API is reachable after every request.
+var offlineHandler func(offline bool)
+
+// SetOfflineHandler sets the function notified whether the API is reachable, the cached responses are served while it is not.
+func SetOfflineHandler(handler func(offline bool)) {
+	offlineHandler = handler
+}
+
+// IsUnreachable returns true if the error has been caused by the API being unreachable rather than by an API error response,
+// the requests cancelled by the caller do not tell anything about the API.
+func IsUnreachable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var urlErr *neturl.Error
+	return errors.As(err, &urlErr)
+}
+
+// setOffline notifies the offline handler.
+func setOffline(offline bool) {
+	if offlineHandler != nil {
+		offlineHandler(offline)
+	}
+}
+
+// SetCacheDir enables the on-disk response cache located in the given directory, so the cached responses survive the launcher restart
+// and are served when the API is unreachable.
+func SetCacheDir(dir string) {
+	responses.mu.Lock()
+	defer responses.mu.Unlock()
+
+	responses.disk = cache.NewStore(dir)
+}
+
+// ClearCache removes the cached responses from memory and disk, the responses are requested from the API again.
+func ClearCache() error {
+	responses.mu.Lock()
+	defer responses.mu.Unlock()
+
+	responses.memory = make(map[string]*cachedResponse)
+
+	if responses.disk == nil {
+		return nil
+	}
+
+	err := os.RemoveAll(responses.disk.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to remove response cache: %w", err)
+	}
+
+	return nil
+}
+
+// key returns the cache key for the given url.
+func (c *responseCache) key(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(hash[:])
+}
+
+// get returns the cached response for the given url or nil if the response has not been cached.
+func (c *responseCache) get(url string) *cachedResponse {
+	c.mu.RLock()
+	r, ok := c.memory[url]
+	disk := c.disk
+	c.mu.RUnlock()
+
+	if ok {
+		return r
+	}
+
+	if disk == nil {
+		return nil
+	}
+
+	var stored cachedResponse
+	if _, err := disk.Get(c.key(url), &stored); err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.memory[url] = &stored
+	c.mu.Unlock()
+
+	return &stored
+}
+
+// put stores the response for the given url in memory and on disk.
+func (c *responseCache) put(ctx context.Context, url string, r *cachedResponse) {
+	c.mu.Lock()
+	c.memory[url] = r
+	disk := c.disk
+	c.mu.Unlock()
+
+	if disk != nil {
+		if err := disk.Put(c.key(url), r); err != nil {
+			runtime.LogWarningf(ctx, "failed to store cached response for %s: %v", url, err)
+		}
+	}
+}
+
+// do calls the function once for all the concurrent calls with the same key and returns its result to each of them. The function
+// gets the context which is not cancelled with the context of any caller, the callers waiting for its result return once their own
+// context is done.
+func (g *requestGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, string, error)) ([]byte, string, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.body, c.etag, c.err
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+
+	// The waiters get ErrorRequestAborted if the function panics.
+	c := &call{done: make(chan struct{}), err: ErrorRequestAborted}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.body, c.etag, c.err = fn(detachedContext{parent: ctx})
+
+	return c.body, c.etag, c.err
+}
+
+// get sends a GET request to the given url and returns the response body and its ETag, the response is served from the cache while it is fresh and revalidated using If-None-Match when it is stale.
+func get(ctx context.Context, url string, what string) ([]byte, string, error) {
+	return requests.do(ctx, url, func(ctx context.Context) ([]byte, string, error) {
+		return fetch(ctx, url, what)
+	})
+}
+
+// fetch requests the given url from the API or the response cache.
+func fetch(ctx context.Context, url string, what string) ([]byte, string, error) {
+	cached := responses.get(url)
+	if cached != nil && time.Now().Before(cached.Expires) {
+		return cached.Body, cached.ETag, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		runtime.LogErrorf(ctx, "failed to create a HTTP request: %v", err)
+		return nil, "", fmt.Errorf("failed to create a HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Serve the cached response regardless of its age if the API is unreachable.
+		if IsUnreachable(err) {
+			setOffline(true)
+			if cached != nil {
+				runtime.LogWarningf(ctx, "api is unreachable, using cached %s: %v", what, err)
+				return cached.Body, cached.ETag, nil
+			}
+		}
+		runtime.LogErrorf(ctx, "failed to send a HTTP GET request: %v", err)
+		return nil, "", fmt.Errorf("failed to send a HTTP GET request: %w", err)
+	}
+	setOffline(false)
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			runtime.LogErrorf(ctx, "error closing http response body: %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		expires, store := getExpiration(resp.Header)
+		if store {
+			responses.put(ctx, url, &cachedResponse{Body: cached.Body, ETag: cached.ETag, Expires: expires})
+		}
+		return cached.Body, cached.ETag, nil
+	}
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			runtime.LogErrorf(ctx, "failed to read response body: %v", err)
+			return nil, "", fmt.Errorf("failed to read response body: %w", err)
+		}
+		runtime.LogErrorf(ctx, "failed to get %s from %s, status code: %d, content: %s", what, url, resp.StatusCode, body)
+		return nil, "", fmt.Errorf("failed to get %s from %s, status code: %d, content: %s", what, url, resp.StatusCode, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		runtime.LogErrorf(ctx, "failed to read response body: %v", err)
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if expires, store := getExpiration(resp.Header); store && json.Valid(body) {
+		responses.put(ctx, url, &cachedResponse{Body: body, ETag: etag, Expires: expires})
+	}
+
+	return body, etag, nil
+}
+
+// getExpiration returns the time the response with the given headers becomes stale and if the response can be stored in the cache at all.
+// The response without the Cache-Control header is stale immediately, so it is revalidated with its ETag on the next request.
+func getExpiration(header http.Header) (time.Time, bool) {
+	var maxAge time.Duration
+	var noCache bool
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			noCache = true
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if noCache {
+		return time.Now(), true
+	}
+
+	return time.Now().Add(maxAge), true
+}
+
+// storeResponse stores the response body for the given url as stale, so it is served while the API is unreachable and revalidated otherwise.
+func storeResponse(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	responses.put(ctx, url, &cachedResponse{Body: body})
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetExpiration(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		maxAge       time.Duration
+		store        bool
+	}{
+		{name: "no header", store: true},
+		{name: "max-age", cacheControl: "max-age=60", maxAge: 60 * time.Second, store: true},
+		{name: "quoted max-age", cacheControl: `max-age="30"`, maxAge: 30 * time.Second, store: true},
+		{name: "invalid max-age", cacheControl: "max-age=soon", store: true},
+		{name: "negative max-age", cacheControl: "max-age=-10", store: true},
+		{name: "mixed case and spacing", cacheControl: "public,  Max-Age=120 ", maxAge: 120 * time.Second, store: true},
+		{name: "no-cache", cacheControl: "no-cache, max-age=60", store: true},
+		{name: "no-store", cacheControl: "max-age=60, no-store", store: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+
+			before := time.Now()
+			expiresAt, store := getExpiration(header)
+			after := time.Now()
+
+			if store != tt.store {
+				t.Fatalf("getExpiration() store = %v, want %v", store, tt.store)
+			}
+			if !store {
+				return
+			}
+
+			if expiresAt.Before(before.Add(tt.maxAge)) || expiresAt.After(after.Add(tt.maxAge)) {
+				t.Errorf("getExpiration() = %v, want now + %v", expiresAt, tt.maxAge)
+			}
+		})
+	}
+}
+
+func TestRequestGroupDo(t *testing.T) {
+	g := &requestGroup{calls: make(map[string]*call)}
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(context.Context) ([]byte, string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return []byte("body"), "etag", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	request := func(i int) {
+		defer wg.Done()
+		body, etag, err := g.do(context.Background(), "key", fn)
+		if err != nil {
+			t.Errorf("do() error = %v", err)
+		}
+		results[i] = string(body) + "/" + etag
+	}
+
+	wg.Add(1)
+	go request(0)
+	<-started
+
+	for i := 1; i < len(results); i++ {
+		wg.Add(1)
+		go request(i)
+	}
+
+	// Let the rest of the requests join the first one before it completes.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	for _, result := range results {
+		if result != "body/etag" {
+			t.Errorf("do() = %q, want %q", result, "body/etag")
+		}
+	}
+
+	// The completed request is forgotten, so the next one reaches the API again.
+	if _, _, err := g.do(context.Background(), "key", func(context.Context) ([]byte, string, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, "", nil
+	}); err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestRequestGroupDoCancel(t *testing.T) {
+	g := &requestGroup{calls: make(map[string]*call)}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, _, err := g.do(context.Background(), "key", func(ctx context.Context) ([]byte, string, error) {
+			close(started)
+			<-release
+			return nil, "", ctx.Err()
+		})
+		done <- err
+	}()
+	<-started
+
+	// The waiter returns once its own context is done, the shared request keeps running.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := g.do(ctx, "key", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("do() of a cancelled waiter error = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("do() of the shared request error = %v, want nil", err)
+	}
+}
+
+func TestRequestGroupDoPanic(t *testing.T) {
+	g := &requestGroup{calls: make(map[string]*call)}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		defer func() {
+			_ = recover()
+		}()
+		_, _, _ = g.do(context.Background(), "key", func(context.Context) ([]byte, string, error) {
+			close(started)
+			<-release
+			panic("failed")
+		})
+	}()
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, _, err := g.do(context.Background(), "key", func(context.Context) ([]byte, string, error) {
+			return nil, "", errors.New("request not shared")
+		})
+		waiter <- err
+	}()
+
+	// Let the waiter join the request before it panics.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-waiter:
+		if !errors.Is(err, ErrorRequestAborted) {
+			t.Errorf("do() error = %v, want ErrorRequestAborted", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("do() waiter blocked after the request panicked")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.calls) != 0 {
+		t.Errorf("calls = %d after the request panicked, want 0", len(g.calls))
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "http://api", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: true},
+		{name: "cancelled", err: &url.Error{Op: "Get", URL: "http://api", Err: context.Canceled}, want: false},
+		{name: "deadline exceeded", err: &url.Error{Op: "Get", URL: "http://api", Err: context.DeadlineExceeded}, want: false},
+		{name: "error response", err: fmt.Errorf("failed to get app from http://api, status code: 500"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("failed to send a HTTP GET request: %w", tt.err)
+			if got := IsUnreachable(err); got != tt.want {
+				t.Errorf("IsUnreachable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,16 +10,14 @@ import (
 	"games.launch.launcher/config"
 	"github.com/gofrs/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
-	"io"
-	"net/http"
 )
 
-// GetLauncherMetadata returns the launcher metadata for the given launcher id.
-func GetLauncherMetadata(ctx context.Context, id uuid.UUID) (*sm.LauncherV2, error) {
+// GetLauncherMetadata returns the launcher metadata for the given launcher id and the ETag of the response.
+func GetLauncherMetadata(ctx context.Context, id uuid.UUID) (*sm.LauncherV2, string, error) {
 	if id.IsNil() {
 		if config.LauncherId == "" {
 			runtime.LogErrorf(ctx, "launcher id is not set")
-			return nil, fmt.Errorf("launcher id is not set")
+			return nil, "", fmt.Errorf("launcher id is not set")
 		} else {
 			id = uuid.FromStringOrNil(config.LauncherId)
 		}
@@ -27,52 +25,21 @@ func GetLauncherMetadata(ctx context.Context, id uuid.UUID) (*sm.LauncherV2, err
 
 	url := fmt.Sprintf("%s/launchers/public/%s?platform=%s", config.Api2Url, id, vUnreal.GetPlatformName())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, etag, err := get(ctx, url, "launcher metadata")
 	if err != nil {
-		runtime.LogErrorf(ctx, "failed to create a HTTP request: %v", err)
-		return nil, fmt.Errorf("failed to create a HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to send a HTTP GET request: %v", err)
-		return nil, fmt.Errorf("failed to send a HTTP GET request: %w", err)
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			runtime.LogErrorf(ctx, "error closing http response body: %v", err)
-		}
-	}(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-		runtime.LogErrorf(ctx, "failed to get launcher metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-		return nil, fmt.Errorf("failed to get launcher metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", err
 	}
 
 	var v sm.Wrapper[sm.LauncherV2]
 	if err = json.Unmarshal(body, &v); err != nil {
 		runtime.LogErrorf(ctx, "failed to unmarshal response: %v", err)
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &v.Payload, err
+	return &v.Payload, etag, nil
 }
 
+// RequestLauncherReleaseMetadata returns a page of the launcher releases.
 func RequestLauncherReleaseMetadata(ctx context.Context, offset int64, limit int64) ([]sm.ReleaseV2, error) {
 	if config.LauncherId == "" {
 		runtime.LogErrorf(ctx, "launcher id is not set")
@@ -81,41 +48,9 @@ func RequestLauncherReleaseMetadata(ctx context.Context, offset int64, limit int
 
 	url := fmt.Sprintf("%s/launchers/public/%s/releases?platform=%s&offset=%d&limit=%d", config.Api2Url, config.LauncherId, vUnreal.GetPlatformName(), offset, limit)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to create a HTTP request: %v", err)
-		return nil, fmt.Errorf("failed to create a HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	body, _, err := get(ctx, url, "launcher releases")
 	if err != nil {
-		runtime.LogErrorf(ctx, "failed to send a HTTP GET request: %v", err)
-		return nil, fmt.Errorf("failed to send a HTTP GET request: %w", err)
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			runtime.LogErrorf(ctx, "error closing http response body: %v", err)
-		}
-	}(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-		runtime.LogErrorf(ctx, "failed to get launcher releases from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-		return nil, fmt.Errorf("failed to get launcher releases from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	var v sm.Wrapper[[]sm.ReleaseV2]
@@ -124,15 +59,15 @@ func RequestLauncherReleaseMetadata(ctx context.Context, offset int64, limit int
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return v.Payload, err
+	return v.Payload, nil
 }
 
-// IndexLauncherApps returns a list of apps for the given launcher id.
-func IndexLauncherApps(ctx context.Context, id uuid.UUID, offset int64, limit int64) ([]sm.AppV2, error) {
+// IndexLauncherApps returns a list of apps for the given launcher id and the ETag of the response.
+func IndexLauncherApps(ctx context.Context, id uuid.UUID, offset int64, limit int64) ([]sm.AppV2, string, error) {
 	if id.IsNil() {
 		if config.LauncherId == "" {
 			runtime.LogErrorf(ctx, "launcher id is not set")
-			return nil, fmt.Errorf("launcher id is not set")
+			return nil, "", fmt.Errorf("launcher id is not set")
 		} else {
 			id = uuid.FromStringOrNil(config.LauncherId)
 		}
@@ -140,103 +75,48 @@ func IndexLauncherApps(ctx context.Context, id uuid.UUID, offset int64, limit in
 
 	url := fmt.Sprintf("%s/launchers/public/%s/apps?platform=%s&offset=%d&limit=%d", config.Api2Url, id, vUnreal.GetPlatformName(), offset, limit)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to create a HTTP request: %v", err)
-		return nil, fmt.Errorf("failed to create a HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	body, etag, err := get(ctx, url, "launcher apps metadata")
 	if err != nil {
-		runtime.LogErrorf(ctx, "failed to send a HTTP GET request: %v", err)
-		return nil, fmt.Errorf("failed to send a HTTP GET request: %w", err)
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			runtime.LogErrorf(ctx, "error closing http response body: %v", err)
-		}
-	}(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-		runtime.LogErrorf(ctx, "failed to get launcher apps metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-		return nil, fmt.Errorf("failed to get launcher apps metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", err
 	}
 
 	var v sm.Wrapper[[]sm.AppV2]
 	if err = json.Unmarshal(body, &v); err != nil {
 		runtime.LogErrorf(ctx, "failed to unmarshal response: %v", err)
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return v.Payload, err
+	return v.Payload, etag, nil
 }
 
-// GetAppMetadata returns the app metadata for the given app id.
-func GetAppMetadata(ctx context.Context, id uuid.UUID) (*sm.AppV2, error) {
+// GetAppMetadata returns the app metadata for the given app id and the ETag of the response.
+func GetAppMetadata(ctx context.Context, id uuid.UUID) (*sm.AppV2, string, error) {
 	if id.IsNil() {
 		runtime.LogErrorf(ctx, "app id is not set")
-		return nil, fmt.Errorf("app id is not set")
-	}
-
-	url := fmt.Sprintf("%s/apps/public/%s?platform=%s", config.Api2Url, id, vUnreal.GetPlatformName())
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to create a HTTP request: %v", err)
-		return nil, fmt.Errorf("failed to create a HTTP request: %w", err)
+		return nil, "", fmt.Errorf("app id is not set")
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	body, etag, err := get(ctx, getAppMetadataUrl(id), "app metadata")
 	if err != nil {
-		runtime.LogErrorf(ctx, "failed to send a HTTP GET request: %v", err)
-		return nil, fmt.Errorf("failed to send a HTTP GET request: %w", err)
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			runtime.LogErrorf(ctx, "error closing http response body: %v", err)
-		}
-	}(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-		runtime.LogErrorf(ctx, "failed to get app metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-		return nil, fmt.Errorf("failed to get launcher metadata from %s, status code: %d, content: %s", url, resp.StatusCode, body)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		runtime.LogErrorf(ctx, "failed to read response body: %v", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", err
 	}
 
 	var v sm.Wrapper[sm.AppV2]
 	if err = json.Unmarshal(body, &v); err != nil {
 		runtime.LogErrorf(ctx, "failed to unmarshal response: %v", err)
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &v.Payload, err
+	return &v.Payload, etag, nil
+}
+
+// StoreAppMetadata stores the app metadata in the response cache as if it has been returned by GetAppMetadata, e.g. for the apps installed
+// from the local packages, so the app is shown while the API is unreachable.
+func StoreAppMetadata(ctx context.Context, id uuid.UUID, app *sm.AppV2) error {
+	return storeResponse(ctx, getAppMetadataUrl(id), sm.Wrapper[sm.AppV2]{Status: "ok", Payload: *app})
+}
+
+// getAppMetadataUrl returns the url of the app metadata.
+func getAppMetadataUrl(id uuid.UUID) string {
+	return fmt.Sprintf("%s/apps/public/%s?platform=%s", config.Api2Url, id, vUnreal.GetPlatformName())
 }
@@ -14,6 +14,7 @@ import (
 	"games.launch.launcher/events"
 	"games.launch.launcher/http"
 	"games.launch.launcher/model"
+	"games.launch.launcher/peer"
 	"games.launch.launcher/utils"
 	"games.launch.launcher/version"
 	"github.com/Masterminds/semver"
@@ -21,12 +22,12 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.org/x/sys/windows"
 	"io"
-	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	goRuntime "runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -36,6 +37,17 @@ var updater embed.FS
 
 var ApplicationsDir = "apps"
 
+// Release file types. The optional component file types are followed by the component tag after a colon, e.g. "release-component-archive:hd-textures".
+const (
+	FileTypeRelease                 = "release"                   // release file of non-archive releases
+	FileTypeReleaseArchive          = "release-archive"           // release archive with the binaries and the content
+	FileTypeReleaseContent          = "release-content"           // content file of non-archive releases, can be updated without the binaries
+	FileTypeReleaseContentArchive   = "release-content-archive"   // release content archive, can be updated without the binaries
+	FileTypeReleaseSdkArchive       = "release-sdk-archive"       // SDK release archive, installed into the shared SDK directory
+	FileTypeReleaseComponent        = "release-component"         // optional component file of non-archive releases
+	FileTypeReleaseComponentArchive = "release-component-archive" // optional component archive, e.g. high resolution textures or a DLC
+)
+
 var ErrorNoUpdateAvailable = errors.New("no update available")
 var ErrorNoMetadata = errors.New("no metadata")
 var ErrorNoReleases = errors.New("no releases")
@@ -43,6 +55,7 @@ var ErrorNoReleaseFiles = errors.New("no release files")
 var ErrorNoReleaseFileSize = errors.New("no release file size")
 var ErrorNoReleaseFileUrl = errors.New("no release file url")
 var ErrorAppInstalled = errors.New("app installed")
+var ErrorAppNotInstalled = errors.New("app not installed")
 var ErrorLauncherIsUpdating = errors.New("launcher is updating")
 var ErrorAppIsUpdating = errors.New("app is updating")
 
@@ -62,12 +75,17 @@ type Launcher struct {
 
 	//region Persistent data
 
-	GameConnPool       map[string]*net.Conn
 	Metadata           *sm.LauncherV2
 	UpdateAvailability UpdateAvailability
 	IsUpdatingLauncher bool
 	IsUpdatingApp      bool
+	IsOffline          bool       // guarded by offlineMutex, use GetIsOffline
+	offlineMutex       sync.Mutex // serializes the offline status changes and their events
 	LastEvent          string
+	Catalog            *Catalog
+	PeerCache          *peer.Cache // the LAN peer cache, nil if disabled
+	Processes          *AppProcesses
+	GameClients        *GameClients
 
 	Status model.Status `json:"status"` // the app status
 	//endregion
@@ -78,7 +96,9 @@ func NewLauncher() *Launcher {
 	return &Launcher{
 		Metadata:           nil,
 		UpdateAvailability: UpdateAvailabilityUnknown,
-		GameConnPool:       make(map[string]*net.Conn),
+		Catalog:            NewCatalog(),
+		Processes:          NewAppProcesses(),
+		GameClients:        NewGameClients(),
 		Status: model.Status{
 			Downloading:     false,
 			Progress:        0,
@@ -91,7 +111,17 @@ func NewLauncher() *Launcher {
 
 // OnStartup is called when the app starts, requests the launcher metadata
 func (l *Launcher) OnStartup(ctx context.Context) {
-	l.Ctx = ctx
+	l.initialize(ctx)
+
+	// Share the downloaded files with the launchers on the local network if enabled.
+	if settings, err := loadSettings(); err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load settings: %v", err)
+	} else if err = l.startPeerCache(settings.PeerCache); err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to start peer cache: %v", err)
+	}
+
+	// Finish the app moves interrupted by the previous launcher exit.
+	go l.resumeAppMoves()
 
 	// Start the first instance and listen for subsequent instance connections.
 	go l.StartFirstInstance()
@@ -112,6 +142,26 @@ func (l *Launcher) OnStartup(ctx context.Context) {
 	//l.EmitEvent(events.LauncherReady)
 }
 
+// initialize stores the app context and opens the caches
+func (l *Launcher) initialize(ctx context.Context) {
+	l.Ctx = ctx
+
+	// Open the API response cache, the cached responses are served when the API is unreachable.
+	api.SetOfflineHandler(l.setOffline)
+	if dir, err := getCacheDir(); err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get cache dir: %v", err)
+	} else {
+		api.SetCacheDir(filepath.Join(dir, responseCacheDir))
+	}
+
+	// Remove the cache left next to the executable by the previous launcher versions.
+	if dir, err := getLegacyCacheDir(); err == nil {
+		if err = os.RemoveAll(dir); err != nil {
+			runtime.LogWarningf(l.Ctx, "failed to remove legacy cache dir: %v", err)
+		}
+	}
+}
+
 // GetLauncherMetadata requests the app metadata from the backend
 func (l *Launcher) GetLauncherMetadata() (*sm.LauncherV2, error) {
 	runtime.LogInfof(l.Ctx, "GetLauncherMetadata")
@@ -124,7 +174,7 @@ func (l *Launcher) GetLauncherMetadata() (*sm.LauncherV2, error) {
 
 	var err error
 	for i := 0; i < RetryCount; i++ {
-		l.Metadata, err = api.GetLauncherMetadata(l.Ctx, launcherId)
+		l.Metadata, _, err = api.GetLauncherMetadata(l.Ctx, launcherId)
 		if err == nil {
 			break
 		}
@@ -201,10 +251,15 @@ func (l *Launcher) UpdateLauncher() error {
 			}
 		}
 
+		// The temp dir may be used by another launcher copy installing an SDK.
 		if _, err := os.Stat(filepath.Join(dir, ".tmp")); err == nil {
-			if err := os.RemoveAll(filepath.Join(dir, ".tmp")); err != nil {
-				runtime.LogErrorf(l.Ctx, "failed to delete temp dir: %v", err)
-				return fmt.Errorf("failed to delete temp dir: %w", err)
+			if lk, err := l.lockDir(filepath.Join(dir, ".tmp")); err == nil {
+				err = os.RemoveAll(filepath.Join(dir, ".tmp"))
+				l.unlockDir(lk)
+				if err != nil {
+					runtime.LogErrorf(l.Ctx, "failed to delete temp dir: %v", err)
+					return fmt.Errorf("failed to delete temp dir: %w", err)
+				}
 			}
 		}
 
@@ -282,6 +337,12 @@ func (l *Launcher) UpdateLauncher() error {
 		return fmt.Errorf("failed to get download dir: %w", err)
 	}
 
+	lk, err := l.lockDir(downloadDir)
+	if err != nil {
+		l.SetLauncherUpdateStatus(false, events.LauncherUpdateFailed)
+		return err
+	}
+
 	var fileName string
 	if file.OriginalPath != nil {
 		fileName = *file.OriginalPath
@@ -295,6 +356,7 @@ func (l *Launcher) UpdateLauncher() error {
 		l.EmitEvent(events.LauncherUpdateProgress, progress, total)
 	})
 	err = http.DownloadFile(l.Ctx, sourcePath, url, counter)
+	l.unlockDir(lk)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to download file: %v", err)
 		return fmt.Errorf("failed to download file: %w", err)
@@ -456,7 +518,7 @@ func (l *Launcher) IndexLauncherApps(offset int64, limit int64) ([]sm.AppV2, err
 	}
 	launcherId := uuid.FromStringOrNil(config.LauncherId)
 
-	apps, err := api.IndexLauncherApps(l.Ctx, launcherId, offset, limit)
+	apps, _, err := api.IndexLauncherApps(l.Ctx, launcherId, offset, limit)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to index launcher apps: %v", err)
 		return nil, fmt.Errorf("failed to index launcher apps: %w", err)
@@ -469,7 +531,7 @@ func (l *Launcher) IndexLauncherApps(offset int64, limit int64) ([]sm.AppV2, err
 
 // GetAppMetadata requests the app metadata from the backend
 func (l *Launcher) GetAppMetadata(id uuid.UUID) (*sm.AppV2, error) {
-	app, err := api.GetAppMetadata(l.Ctx, id)
+	app, _, err := api.GetAppMetadata(l.Ctx, id)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to get app: %v", err)
 		return nil, fmt.Errorf("failed to get app: %w", err)
@@ -563,25 +625,18 @@ func (l *Launcher) getAppExecutable(id uuid.UUID, name string) (string, error) {
 	var err error
 
 	if l.Metadata == nil {
+		// Installed apps must stay launchable without the launcher metadata, e.g. when offline.
 		_, err = l.GetLauncherMetadata()
 		if err != nil {
-			runtime.LogErrorf(l.Ctx, "failed to get launcher metadata: %v", err)
-			return "", err
+			runtime.LogWarningf(l.Ctx, "failed to get launcher metadata: %v", err)
 		}
 	}
 
-	// Get the path to the executable
-	executablePath, err := os.Executable()
+	applicationsDir, err := l.getAppInstallationDir(id)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to get executable path: %v", err)
-		return "", fmt.Errorf("failed to get executable path: %w", err)
+		return "", fmt.Errorf("failed to get app installation dir: %w", err)
 	}
 
-	// Get the directory containing the executable
-	executableDir := filepath.Dir(executablePath)
-
-	applicationsDir := filepath.Join(executableDir, ApplicationsDir, id.String())
-
 	_, err = os.Stat(applicationsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -592,6 +647,12 @@ func (l *Launcher) getAppExecutable(id uuid.UUID, name string) (string, error) {
 		return "", fmt.Errorf("failed to stat app directory: %w", err)
 	}
 
+	return l.findAppExecutable(applicationsDir, id, name)
+}
+
+// findAppExecutable looks up the executable of the app in the given directory by the app id, the app name, the generic name or any executable file
+func (l *Launcher) findAppExecutable(applicationsDir string, id uuid.UUID, name string) (string, error) {
+	var err error
 	var appPath string
 	if !id.IsNil() {
 		appPath, err = l.getAppExecutableById(applicationsDir, id)
@@ -689,15 +750,15 @@ func (l *Launcher) getAppExecutable(id uuid.UUID, name string) (string, error) {
 
 // IsAppInstalled returns true if the app is installed and is ready to launch
 func (l *Launcher) IsAppInstalled(id uuid.UUID) (bool, error) {
-	app, err := l.GetAppMetadata(id)
+	name, err := l.getAppName(id)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
 		return false, fmt.Errorf("failed to get app metadata: %w", err)
 	}
 
-	runtime.LogWarningf(l.Ctx, "check if app is installed: %+v", app)
+	runtime.LogWarningf(l.Ctx, "check if app is installed: %s", id)
 
-	appExe, err := l.getAppExecutable(id, app.Name)
+	appExe, err := l.getAppExecutable(id, name)
 	if err != nil && !os.IsNotExist(err) {
 		runtime.LogErrorf(l.Ctx, "failed to get app executable: %v", err)
 		return false, fmt.Errorf("failed to get app executable: %w", err)
@@ -711,6 +772,20 @@ func (l *Launcher) IsAppInstalled(id uuid.UUID) (bool, error) {
 	return true, nil
 }
 
+// getAppName returns the name of the app used to find its executable, the name is empty if the API is unreachable and the app metadata has never been cached
+func (l *Launcher) getAppName(id uuid.UUID) (string, error) {
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		if api.IsUnreachable(err) {
+			runtime.LogWarningf(l.Ctx, "app metadata is not available offline, looking up the executable without the app name: %v", err)
+			return "", nil
+		}
+		return "", err
+	}
+
+	return app.Name, nil
+}
+
 // CheckForAppUpdates checks for application updates
 func (l *Launcher) CheckForAppUpdates(id uuid.UUID) (UpdateAvailability, error) {
 	dir, err := l.getAppInstallationDir(id)
@@ -770,8 +845,23 @@ func (l *Launcher) CheckForAppUpdates(id uuid.UUID) (UpdateAvailability, error)
 	return l.UpdateAvailability, nil
 }
 
-// InstallApp installs the app with the given id
+// InstallApp installs the app with the given id into the default library
 func (l *Launcher) InstallApp(id uuid.UUID) error {
+	return l.installApp(id, "", nil)
+}
+
+// InstallAppWithComponents installs the app with the given id and the selected optional components into the default library
+func (l *Launcher) InstallAppWithComponents(id uuid.UUID, components []string) error {
+	return l.installApp(id, "", components)
+}
+
+// InstallAppToLibrary installs the app with the given id and the selected optional components into the given library folder
+func (l *Launcher) InstallAppToLibrary(id uuid.UUID, library string, components []string) error {
+	return l.installApp(id, library, components)
+}
+
+// installApp installs the app with the given id and the selected optional components into the library folder, the default library is used if the library is empty
+func (l *Launcher) installApp(id uuid.UUID, library string, components []string) (err error) {
 	if l.IsUpdatingApp {
 		return ErrorAppIsUpdating
 	}
@@ -807,39 +897,158 @@ func (l *Launcher) InstallApp(id uuid.UUID) error {
 		runtime.LogWarningf(l.Ctx, "app is not installed")
 	}
 
+	release := app.Releases.Entities[0]
+
+	// Remember the app location, so it is found in the library after the installation.
+	appInstallationPath, err := registerAppInstallation(id, library)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to register app installation: %v", err)
+		return fmt.Errorf("failed to register app installation: %w", err)
+	}
+
+	// Another launcher copy may be installing the app into the same directory.
+	lk, err := l.lockDir(appInstallationPath)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
+	// The location is kept if the lock fails, as another launcher copy is installing the app there.
+	defer func() {
+		l.forgetFailedAppInstallation(id, err)
+	}()
+
+	required := getReleaseFilesSize(getReleaseFiles(release, FileTypeRelease, FileTypeReleaseArchive, FileTypeReleaseContent, FileTypeReleaseContentArchive))
+	err = checkLibrarySpace(filepath.Dir(appInstallationPath), required)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to check library disk space: %v", err)
+		return err
+	}
+
 	l.IsUpdatingApp = true
 
-	release := app.Releases.Entities[0]
+	// Install the SDK required by the app into the shared SDK directory.
+	err = l.installAppSdk(*app)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to install app sdk: %v", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to install sdk")
+		return fmt.Errorf("failed to install app sdk: %w", err)
+	}
+
 	if release.Archive {
-		return l.installAppReleaseArchive(*app, release)
+		err = l.installAppReleaseArchive(*app, release, false, components)
 	} else {
-		return l.installAppRelease(*app, release)
+		err = l.installAppRelease(*app, release, false, components)
 	}
+	if err != nil {
+		return err
+	}
+
+	// Continue the deep link action which has offered the installation.
+	l.resumeDeepLink(id)
+
+	return nil
 }
 
-// LaunchApp launches the app with the given id
+// LaunchApp launches the app with the given id using its default launch profile
 func (l *Launcher) LaunchApp(id uuid.UUID) error {
-	app, err := l.GetAppMetadata(id)
+	return l.launchAppWithArguments(id, nil)
+}
+
+// launchAppWithArguments launches the app using its default launch profile, the extra arguments, e.g. the deep link destination, are passed before the profile arguments
+func (l *Launcher) launchAppWithArguments(id uuid.UUID, extra []string) error {
+	settings, err := getAppSettings(id)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to get app settings: %v", err)
+	}
+
+	if settings.DefaultProfile == "" {
+		return l.launchApp(id, settings, nil, extra)
+	}
+
+	i := findLaunchProfile(settings, settings.DefaultProfile)
+	if i < 0 {
+		runtime.LogErrorf(l.Ctx, "launch profile %s not found for app %s", settings.DefaultProfile, id)
+		return ErrorProfileNotFound
+	}
+
+	return l.launchApp(id, settings, &settings.Profiles[i], extra)
+}
+
+// launchApp launches the app with the options of the profile and the extra arguments, the app is launched without the profile options if the profile is nil
+func (l *Launcher) launchApp(id uuid.UUID, settings model.AppSettings, profile *model.LaunchProfile, extra []string) error {
+	// Prevent the accidental double launch unless the app allows multiple instances, the launch is reserved before
+	// the metadata request, so a second launch request can not pass the check while the first one is starting.
+	if !l.Processes.TryReserve(id.String(), settings.MultipleInstances) {
+		runtime.LogWarningf(l.Ctx, "app %s is already running", id)
+		return ErrorAppIsRunning
+	}
+	defer l.Processes.Release(id.String())
+
+	name, err := l.getAppName(id)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
 		return fmt.Errorf("failed to get app metadata: %w", err)
 	}
 
-	appExe, err := l.getAppExecutable(id, app.Name)
+	appExe, err := l.getAppExecutable(id, name)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to get app executable: %v", err)
 		return fmt.Errorf("failed to get app executable: %w", err)
 	}
 
-	cmd := exec.Command(appExe)
-	cmd.Dir = filepath.Dir(appExe)
-	err = cmd.Start()
+	// The extra arguments go first, as Unreal expects the server address to travel to as the first argument.
+	args := append([]string{}, extra...)
+	env := os.Environ()
+
+	// The profile environment is merged first, so it can not replace the variables set by the launcher below.
+	if profile != nil {
+		env = mergeEnvironment(env, l.getProfileEnvironment(profile))
+	}
+
+	// Pass the shared SDK directory to the app.
+	sdk, err := l.getAppSdk(id)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to start app: %v", err)
-		return fmt.Errorf("failed to start app: %w", err)
+		runtime.LogWarningf(l.Ctx, "failed to get app sdk: %v", err)
+	} else if sdk != nil {
+		args = append(args, "-SdkPath="+sdk.Path)
+		env = append(env, SdkPathEnv+"="+sdk.Path)
 	}
 
-	return nil
+	// Pass the game client channel endpoint to the app with the secret the game presents to receive the session token.
+	var secret string
+	if address, ok := gameEndpointAddress.Load().(string); ok {
+		env = append(env, GameEndpointEnv+"="+address)
+
+		secret, err = newGameSecret(id.String())
+		if err != nil {
+			runtime.LogWarningf(l.Ctx, "%v", err)
+		} else {
+			env = append(env, GameSecretEnv+"="+secret)
+		}
+	}
+
+	dir := filepath.Dir(appExe)
+
+	if profile != nil {
+		runtime.LogInfof(l.Ctx, "launching app %s with profile %s", id, profile.Name)
+
+		args = append(args, profile.Arguments...)
+
+		if profile.WorkingDir != "" {
+			if filepath.IsAbs(profile.WorkingDir) {
+				dir = profile.WorkingDir
+			} else {
+				dir = filepath.Join(dir, profile.WorkingDir)
+			}
+		}
+	}
+
+	cmd := exec.Command(appExe, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	return l.startAppProcess(id, cmd, secret)
 }
 
 func (l *Launcher) UpdateApp(id uuid.UUID) error {
@@ -863,15 +1072,52 @@ func (l *Launcher) UpdateApp(id uuid.UUID) error {
 		return fmt.Errorf("no releases found for app %s", app.Id)
 	}
 
+	if l.Processes.IsRunning(id.String()) {
+		runtime.LogErrorf(l.Ctx, "app %s is running", id)
+		return ErrorAppIsRunning
+	}
+
 	runtime.LogWarningf(l.Ctx, "updating app %s", app.Id)
 
+	dir, err := l.getAppInstallationDir(id)
+	if err != nil {
+		return fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+
+	lk, err := l.lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
 	l.IsUpdatingApp = true
 
+	// Update the SDK required by the app, the previous SDK release is removed if no other app uses it.
+	err = l.installAppSdk(*app)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to update app sdk: %v", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to update sdk")
+		return fmt.Errorf("failed to update app sdk: %w", err)
+	}
+
 	release := app.Releases.Entities[0]
+
+	// Download only the content if the binaries have not changed since the installed release.
+	contentOnly := l.isContentOnlyUpdate(id, release)
+	if contentOnly {
+		runtime.LogInfof(l.Ctx, "updating app %s content only", app.Id)
+	}
+
+	// Reinstall the previously selected optional components from the new release.
+	components, err := getInstalledComponents(dir)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to get installed components: %v", err)
+	}
+
 	if release.Archive {
-		return l.installAppReleaseArchive(*app, release)
+		return l.installAppReleaseArchive(*app, release, contentOnly, components)
 	} else {
-		return l.installAppRelease(*app, release)
+		return l.installAppRelease(*app, release, contentOnly, components)
 	}
 }
 
@@ -882,6 +1128,11 @@ func (l *Launcher) DeleteApp(id uuid.UUID) error {
 		return err
 	}
 
+	if l.Processes.IsRunning(id.String()) {
+		runtime.LogErrorf(l.Ctx, "app %s is running", id)
+		return ErrorAppIsRunning
+	}
+
 	runtime.LogWarningf(l.Ctx, "deleting app %s", app.Id.String())
 
 	installed, err := l.IsAppInstalled(id)
@@ -901,6 +1152,12 @@ func (l *Launcher) DeleteApp(id uuid.UUID) error {
 		return fmt.Errorf("failed to get app installation directory: %w", err)
 	}
 
+	lk, err := l.lockDir(appInstallationDir)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
 	err = os.RemoveAll(appInstallationDir)
 	if err != nil {
 		runtime.LogErrorf(l.Ctx, "failed to remove app directory: %s", err)
@@ -908,6 +1165,17 @@ func (l *Launcher) DeleteApp(id uuid.UUID) error {
 		return fmt.Errorf("failed to remove app directory: %w", err)
 	}
 
+	err = unregisterAppInstallation(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to unregister app installation: %s", err)
+	}
+
+	// Remove the SDK used by the app if no other app uses it.
+	err = l.releaseAppSdk(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to release app sdk: %s", err)
+	}
+
 	return nil
 }
 
@@ -949,102 +1217,212 @@ func (l *Launcher) OpenUrl(url string) error {
 	return nil
 }
 
-// getAppInstallationDir returns the installation directory of the app with the given id
+// getAppInstallationDir returns the installation directory of the app with the given id registered in the library registry
 func (l *Launcher) getAppInstallationDir(id uuid.UUID) (string, error) {
-	runtime.LogDebugf(l.Ctx, "getting working directory...")
+	dir, err := lookupAppInstallationDir(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app installation dir: %s", err)
+		return "", err
+	}
 
-	// Get the path to the executable
-	executablePath, err := os.Executable()
+	runtime.LogDebugf(l.Ctx, "app installation dir: %s", dir)
+	return dir, nil
+}
+
+// getReleaseFiles returns the release files of the given types
+func getReleaseFiles(release sm.ReleaseV2, types ...string) []*sm.File {
+	var files []*sm.File
+	if release.Files == nil {
+		return files
+	}
+
+	for i, file := range release.Files.Entities {
+		for _, t := range types {
+			if file.Type == t {
+				files = append(files, &release.Files.Entities[i])
+				break
+			}
+		}
+	}
+
+	return files
+}
+
+// getReleaseFilesSize returns the total size of the given release files
+func getReleaseFilesSize(files []*sm.File) uint64 {
+	var size uint64
+	for _, file := range files {
+		if file.Size != nil {
+			size += uint64(*file.Size)
+		}
+	}
+	return size
+}
+
+// parseReleaseVersions returns the code and content versions of the release, versions are nil if they are not set or invalid
+func parseReleaseVersions(release sm.ReleaseV2) (*semver.Version, *semver.Version) {
+	var codeVersion, contentVersion *semver.Version
+	if v, err := semver.NewVersion(release.CodeVersion); err == nil {
+		codeVersion = v
+	}
+	if v, err := semver.NewVersion(release.ContentVersion); err == nil {
+		contentVersion = v
+	}
+	return codeVersion, contentVersion
+}
+
+// isContentOnlyUpdate returns true if the installed app has the same binaries as the release, so only the release content has to be downloaded
+func (l *Launcher) isContentOnlyUpdate(id uuid.UUID, release sm.ReleaseV2) bool {
+	dir, err := l.getAppInstallationDir(id)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to get executable path: %s", err)
-		return "", fmt.Errorf("failed to get executable path: %w", err)
+		runtime.LogWarningf(l.Ctx, "failed to get app installation dir: %v", err)
+		return false
 	}
 
-	// Get the directory containing the executable
-	executableDir := filepath.Dir(executablePath)
+	record, err := version.ReadRecord(dir)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to read app version record: %v", err)
+		return false
+	}
 
-	runtime.LogDebugf(l.Ctx, "working directory: %s", executableDir)
-	return filepath.Join(executableDir, ApplicationsDir, id.String()), nil
+	if record.CodeVersion == nil || record.ContentVersion == nil {
+		// The app has been installed before the code and content versions were tracked.
+		return false
+	}
+
+	codeVersion, contentVersion := parseReleaseVersions(release)
+	if codeVersion == nil || contentVersion == nil {
+		return false
+	}
+
+	if !codeVersion.Equal(record.CodeVersion) || contentVersion.Equal(record.ContentVersion) {
+		return false
+	}
+
+	// The release must ship the content separately from the binaries.
+	if release.Archive {
+		return len(getReleaseFiles(release, FileTypeReleaseContentArchive)) > 0
+	}
+	return len(getReleaseFiles(release, FileTypeReleaseContent)) > 0
 }
 
-func (l *Launcher) installAppReleaseArchive(app sm.AppV2, release sm.ReleaseV2) error {
+// writeAppReleaseVersion writes the release, code and content versions to the app installation directory
+func (l *Launcher) writeAppReleaseVersion(dir string, release sm.ReleaseV2) error {
+	runtime.LogDebugf(l.Ctx, "parsing release version: %s...", release.Version)
+	v, err := semver.NewVersion(release.Version)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to parse release version: %s", err)
+		return fmt.Errorf("failed to parse release version: %w", err)
+	}
+	runtime.LogDebugf(l.Ctx, "parsed release version: %s", v.String())
+
+	record := &version.Record{Version: v}
+	record.CodeVersion, record.ContentVersion = parseReleaseVersions(release)
+
+	runtime.LogDebugf(l.Ctx, "writing version to %s...", dir)
+	err = version.WriteRecord(dir, record)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to write version: %s", err)
+		return fmt.Errorf("failed to write version: %w", err)
+	}
+	runtime.LogDebugf(l.Ctx, "wrote version to %s", dir)
+
+	return nil
+}
+
+// installAppReleaseArchive downloads and extracts the release archives and the selected optional components, only the content archives are installed if contentOnly is set
+func (l *Launcher) installAppReleaseArchive(app sm.AppV2, release sm.ReleaseV2, contentOnly bool, components []string) error {
 	runtime.LogDebugf(l.Ctx, "installing app release archive: %+v", release)
 
 	id := app.Id
 
-	runtime.LogDebugf(l.Ctx, "getting archive file...")
-	var archive *sm.File
-	for _, file := range release.Files.Entities {
-		if file.Type == "release-archive" {
-			runtime.LogDebugf(l.Ctx, "found archive file %s: %s", file.Id, file.Url)
-			archive = &file
-			break
+	runtime.LogDebugf(l.Ctx, "getting archive files...")
+	var archives []*sm.File
+	if contentOnly {
+		archives = getReleaseFiles(release, FileTypeReleaseContentArchive)
+	} else {
+		archives = getReleaseFiles(release, FileTypeReleaseArchive)
+		if len(archives) > 0 {
+			archives = append(archives[:1], getReleaseFiles(release, FileTypeReleaseContentArchive)...)
 		}
 	}
-	if archive == nil {
+	if len(archives) == 0 {
 		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "no archive file found")
 		return fmt.Errorf("no archive file found")
 	}
-	runtime.LogDebugf(l.Ctx, "archive file found: %+v", archive)
+	for _, archive := range archives {
+		runtime.LogDebugf(l.Ctx, "found archive file %s: %s", archive.Id, archive.Url)
+	}
 
-	runtime.LogDebugf(l.Ctx, "getting working directory...")
-	// Get the path to the executable
-	executablePath, err := os.Executable()
+	appInstallationPath, err := l.getAppInstallationDir(*id)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to get executable path: %s", err)
-		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to get executable path")
-		return fmt.Errorf("failed to get executable path: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to get app installation dir")
+		return fmt.Errorf("failed to get app installation dir: %w", err)
 	}
+	runtime.LogDebugf(l.Ctx, "app installation path: %s", appInstallationPath)
 
-	// Get the directory containing the executable
-	executableDir := filepath.Dir(executablePath)
-	runtime.LogDebugf(l.Ctx, "working directory: %s", executableDir)
+	err = removeAppManifest(appInstallationPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "%v", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to remove manifest")
+		return err
+	}
 
-	tempDownloadPath := filepath.Join(executableDir, ".tmp", id.String())
+	// Download into the library folder, so the files are moved within the same drive.
+	tempDownloadPath := filepath.Join(filepath.Dir(appInstallationPath), ".tmp", id.String())
 	runtime.LogDebugf(l.Ctx, "temp download path: %s", tempDownloadPath)
-	appInstallationPath := filepath.Join(executableDir, ApplicationsDir, id.String())
-	runtime.LogDebugf(l.Ctx, "app installation path: %s", appInstallationPath)
 
-	counter := http.NewDownloadProgressTracker((uint64)(*archive.Size), func(progress uint64, total uint64) {
-		l.EmitEvent(events.AppUpdateProgress, app, progress, total)
-	})
-	runtime.LogDebugf(l.Ctx, "downloading file to %s...", tempDownloadPath)
-	err = http.DownloadFile(l.Ctx, tempDownloadPath, archive.Url, counter)
+	// Keep the storage cleanup of another launcher copy from removing the download.
+	lk, err := l.lockDir(tempDownloadPath)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to download file: %s", err)
-		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to download file")
-		return fmt.Errorf("failed to download file: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "download dir is locked")
+		return err
 	}
-	runtime.LogDebugf(l.Ctx, "downloaded file to %s", tempDownloadPath)
+	defer l.unlockDir(lk)
 
-	l.SetAppUpdateStatus(false, events.AppUpdateExtracting, app)
+	totalSize := getReleaseFilesSize(archives)
+	var completed uint64
 
-	runtime.LogDebugf(l.Ctx, "extracting archive to %s...", appInstallationPath)
-	err = utils.ExtractArchive(l.Ctx, tempDownloadPath, appInstallationPath)
-	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to extract archive: %s", err)
-		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to extract archive")
-		return fmt.Errorf("failed to extract archive: %w", err)
+	for _, archive := range archives {
+		archivePath := filepath.Join(tempDownloadPath, archive.Id.String())
+
+		counter := http.NewDownloadProgressTracker(totalSize, func(progress uint64, _ uint64) {
+			l.EmitEvent(events.AppUpdateProgress, app, completed+progress, totalSize)
+		})
+		runtime.LogDebugf(l.Ctx, "downloading file to %s...", archivePath)
+		shared, err := l.downloadReleaseFile(archive, archivePath, counter)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to download file: %s", err)
+			l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to download file")
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+		runtime.LogDebugf(l.Ctx, "downloaded file to %s", archivePath)
+
+		if archive.Size != nil {
+			completed += uint64(*archive.Size)
+		}
+
+		err = l.extractAppArchive(app, archivePath, appInstallationPath)
+		if err != nil {
+			return err
+		}
+
+		if shared {
+			l.keepPeerFile(archive, archivePath)
+		}
 	}
-	runtime.LogDebugf(l.Ctx, "extracted archive to %s", appInstallationPath)
 
-	runtime.LogDebugf(l.Ctx, "parsing release version: %s...", release.Version)
-	v, err := semver.NewVersion(release.Version)
+	err = l.installAppComponents(app, release, appInstallationPath, components)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to parse release version: %s", err)
-		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to parse release version")
-		return fmt.Errorf("failed to parse release version: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to install components")
+		return err
 	}
-	runtime.LogDebugf(l.Ctx, "parsed release version: %s", v.String())
 
-	runtime.LogDebugf(l.Ctx, "writing version to %s...", appInstallationPath)
-	err = version.WriteVersion(appInstallationPath, v)
+	err = l.writeAppReleaseVersion(appInstallationPath, release)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to write version: %s", err)
 		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to write version")
-		return fmt.Errorf("failed to write version: %w", err)
+		return err
 	}
-	runtime.LogDebugf(l.Ctx, "wrote version to %s", appInstallationPath)
 
 	runtime.LogDebugf(l.Ctx, "removing temporary download directory %s...", tempDownloadPath)
 	err = os.RemoveAll(tempDownloadPath)
@@ -1060,86 +1438,154 @@ func (l *Launcher) installAppReleaseArchive(app sm.AppV2, release sm.ReleaseV2)
 	return nil
 }
 
-func (l *Launcher) installAppRelease(app sm.AppV2, release sm.ReleaseV2) error {
+// forgetFailedAppInstallation unregisters the app location if the installation has failed, so the registry does not point at a missing or partial installation
+func (l *Launcher) forgetFailedAppInstallation(id uuid.UUID, err error) {
+	if err == nil {
+		return
+	}
+
+	if unregisterErr := unregisterAppInstallation(id); unregisterErr != nil {
+		runtime.LogErrorf(l.Ctx, "failed to unregister app installation: %v", unregisterErr)
+	}
+}
+
+// extractAppArchive extracts the release archive into the app installation directory, the skipped slash separated archive paths are not extracted
+func (l *Launcher) extractAppArchive(app sm.AppV2, archivePath string, appInstallationPath string, skip ...string) error {
+	l.SetAppUpdateStatus(true, events.AppUpdateExtracting, app)
+
+	runtime.LogDebugf(l.Ctx, "extracting archive to %s...", appInstallationPath)
+	_, err := utils.ExtractArchiveFiles(l.Ctx, archivePath, appInstallationPath, skip...)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to extract archive: %s", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to extract archive")
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+	runtime.LogDebugf(l.Ctx, "extracted archive to %s", appInstallationPath)
+
+	return nil
+}
+
+// installAppRelease downloads the release files and the selected optional components, only the content files are installed if contentOnly is set
+func (l *Launcher) installAppRelease(app sm.AppV2, release sm.ReleaseV2, contentOnly bool, components []string) error {
 	runtime.LogDebugf(l.Ctx, "installing app release: %+v", release)
 
 	id := app.Id
 
 	var files []*sm.File
-	for _, file := range release.Files.Entities {
-		if file.Type == "release" {
-			runtime.LogDebugf(l.Ctx, "found release file %s: %s", file.Id, file.Url)
-			files = append(files, &file)
-		}
+	if contentOnly {
+		files = getReleaseFiles(release, FileTypeReleaseContent)
+	} else {
+		files = getReleaseFiles(release, FileTypeRelease, FileTypeReleaseContent)
+	}
+	for _, file := range files {
+		runtime.LogDebugf(l.Ctx, "found release file %s: %s", file.Id, file.Url)
 	}
 
 	if len(files) == 0 {
 		runtime.LogErrorf(l.Ctx, "no release files found")
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "no release files found")
 		return fmt.Errorf("no release files found")
 	}
 
-	// Get the path to the executable
-	executablePath, err := os.Executable()
+	appInstallationPath, err := l.getAppInstallationDir(*id)
 	if err != nil {
-		runtime.LogErrorf(l.Ctx, "failed to get executable path: %s", err)
-		return fmt.Errorf("failed to get executable path: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to get app installation dir")
+		return fmt.Errorf("failed to get app installation dir: %w", err)
 	}
 
-	// Get the directory containing the executable
-	executableDir := filepath.Dir(executablePath)
+	err = removeAppManifest(appInstallationPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "%v", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to remove manifest")
+		return err
+	}
 
-	tempDownloadPath := filepath.Join(executableDir, ".tmp", id.String())
-	appInstallationPath := filepath.Join(executableDir, ApplicationsDir, id.String())
+	// Download into the library folder, so the files are moved within the same drive.
+	tempDownloadPath := filepath.Join(filepath.Dir(appInstallationPath), ".tmp", id.String())
 
-	var totalProgress uint64 = 0
-	var totalSize uint64 = 0
+	// Keep the storage cleanup of another launcher copy from removing the download.
+	lk, err := l.lockDir(tempDownloadPath)
+	if err != nil {
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "download dir is locked")
+		return err
+	}
+	defer l.unlockDir(lk)
 
 	// calculate total size for all files
-	for _, file := range files {
-		totalSize += uint64(*file.Size)
-	}
+	totalSize := getReleaseFilesSize(files)
+	var completed uint64
+	shared := make(map[*sm.File]bool)
 
 	runtime.LogDebugf(l.Ctx, "total size: %d", totalSize)
 
 	for _, file := range files {
-		counter := http.NewDownloadProgressTracker(totalSize, func(progress uint64, total uint64) {
+		if file.OriginalPath == nil {
+			runtime.LogErrorf(l.Ctx, "file %s has no original path", file.Id)
+			continue
+		}
+
+		counter := http.NewDownloadProgressTracker(totalSize, func(progress uint64, _ uint64) {
 			// accumulate progress for all files and report it to the frontend as total progress
-			totalProgress += progress
-			l.EmitEvent(events.AppUpdateProgress, totalProgress, total)
+			l.EmitEvent(events.AppUpdateProgress, app, completed+progress, totalSize)
 		})
 		// download next file
-		err = http.DownloadFile(l.Ctx, tempDownloadPath, file.Url, counter)
+		shared[file], err = l.downloadReleaseFile(file, filepath.Join(tempDownloadPath, *file.OriginalPath), counter)
 		if err != nil {
 			runtime.LogErrorf(l.Ctx, "failed to download file: %s", err.Error())
+			l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to download file")
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+
+		if file.Size != nil {
+			completed += uint64(*file.Size)
 		}
 	}
 
 	for _, file := range files {
 		if file.OriginalPath == nil {
-			runtime.LogErrorf(l.Ctx, "file %s has no original path", file.Id)
 			continue
 		}
-		err = os.Rename(filepath.Join(tempDownloadPath, *file.OriginalPath), filepath.Join(appInstallationPath, *file.OriginalPath))
+
+		destinationPath := filepath.Join(appInstallationPath, *file.OriginalPath)
+		err = os.MkdirAll(filepath.Dir(destinationPath), 0755)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to create directory: %s", err.Error())
+			l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to create directory")
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		err = os.Rename(filepath.Join(tempDownloadPath, *file.OriginalPath), destinationPath)
 		if err != nil {
 			runtime.LogErrorf(l.Ctx, "failed to move file: %s", err.Error())
+			l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to move file")
+			return fmt.Errorf("failed to move file: %w", err)
+		}
+
+		if shared[file] {
+			l.addPeerFile(file, destinationPath)
 		}
 	}
 
-	v, err := semver.NewVersion(release.Version)
+	err = l.installAppComponents(app, release, appInstallationPath, components)
 	if err != nil {
-		return fmt.Errorf("failed to parse release version: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to install components")
+		return err
 	}
 
-	err = version.WriteVersion(appInstallationPath, v)
+	err = l.writeAppReleaseVersion(appInstallationPath, release)
 	if err != nil {
-		return fmt.Errorf("failed to write version: %w", err)
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to write version")
+		return err
 	}
 
 	err = os.RemoveAll(tempDownloadPath)
 	if err != nil {
+		l.SetAppUpdateStatus(false, events.AppUpdateCompleted, app, "failed to remove temporary download directory")
 		return fmt.Errorf("failed to remove temporary download directory: %w", err)
 	}
 
+	l.SetAppUpdateStatus(false, events.AppUpdateCompleted, app)
+
 	return nil
 }
 
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"crypto/rand"
+	vContext "dev.hackerman.me/artheon/veverse-shared/context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"games.launch.launcher/api"
+	"games.launch.launcher/game"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"sync"
+)
+
+var ErrorEmptySessionToken = errors.New("session token is empty")
+
+// sessionMu serializes the session token changes, so the game clients receive the tokens in the order they have been set.
+var sessionMu sync.Mutex
+
+// sessionToken is the session token (JWT) of the signed-in user pushed to the game clients, empty if the user is signed out.
+var sessionToken string
+
+// gameSecrets are the secrets passed to the launched app processes, the game clients present them in the hello to receive the session token.
+// The app ids are indexed by the secret, a secret is forgotten when its process exits.
+var gameSecrets sync.Map
+
+// newGameSecret generates the secret passed to the launched app process
+func newGameSecret(appId string) (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate game secret: %w", err)
+	}
+
+	secret := hex.EncodeToString(b)
+	gameSecrets.Store(secret, appId)
+
+	return secret, nil
+}
+
+// forgetGameSecret forgets the secret of the exited app process
+func forgetGameSecret(secret string) {
+	if secret != "" {
+		gameSecrets.Delete(secret)
+	}
+}
+
+// isGameSecretValid returns true if the secret has been passed to a running process of the app
+func isGameSecretValid(appId string, secret string) bool {
+	id, ok := gameSecrets.Load(secret)
+	return ok && id.(string) == appId
+}
+
+// Login signs the user in to the project, saves the session for the legacy game clients and pushes the session token to the connected game clients
+func (l *Launcher) Login(project string, email string, password string) error {
+	ctx, err := api.Login(l.Ctx, project, email, password)
+
+	var token string
+	if ctx != nil {
+		token, _ = ctx.Value(vContext.Token).(string)
+	}
+	if token == "" {
+		if err == nil {
+			err = ErrorEmptySessionToken
+		}
+		return err
+	}
+
+	// The game clients receive the token even if the session could not be saved.
+	if setErr := l.SetSessionToken(token); setErr != nil {
+		return setErr
+	}
+
+	return err
+}
+
+// SetSessionToken stores the session token when the user signs in or the token is renewed and pushes it to the connected game clients
+func (l *Launcher) SetSessionToken(token string) error {
+	if token == "" {
+		return ErrorEmptySessionToken
+	}
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	if token == sessionToken {
+		return nil
+	}
+	sessionToken = token
+
+	l.broadcastSessionMessage(game.Message{Type: game.TypeSession, Token: token})
+
+	return nil
+}
+
+// Logout drops the session token and notifies the connected game clients, so they stop using the revoked session
+func (l *Launcher) Logout() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	sessionToken = ""
+
+	l.broadcastSessionMessage(game.Message{Type: game.TypeLogout})
+}
+
+// broadcastSessionMessage sends the session message to the trusted game clients only, the failures are logged
+func (l *Launcher) broadcastSessionMessage(message game.Message) {
+	l.GameClients.Each(func(session *game.Session) {
+		if !session.IsTrusted() {
+			return
+		}
+
+		err := session.Send(message)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to send %s message to game client %s: %v", message.Type, session.Client().Id, err)
+		}
+	})
+}
+
+// sendSessionToken pushes the current session token to the newly connected game client, nothing is sent if the user is signed out or the client is not trusted
+func (l *Launcher) sendSessionToken(session *game.Session) {
+	if !session.IsTrusted() {
+		return
+	}
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	if sessionToken == "" {
+		return
+	}
+
+	err := session.Send(game.Message{Type: game.TypeSession, Token: sessionToken})
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to send session token to game client %s: %v", session.Client().Id, err)
+	}
+}
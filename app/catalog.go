@@ -0,0 +1,245 @@
+package app
+
+import (
+	"bytes"
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"encoding/json"
+	"fmt"
+	"games.launch.launcher/api"
+	"games.launch.launcher/config"
+	"games.launch.launcher/events"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"strings"
+	"sync"
+)
+
+// CatalogPageSize is the number of apps requested from the backend per catalog page.
+const CatalogPageSize int64 = 50
+
+// CatalogMaxPages is the maximum number of catalog pages requested per sync, so a backend ignoring the offset can not keep the sync running forever.
+const CatalogMaxPages = 200
+
+// Catalog is the local index of all the launcher apps.
+type Catalog struct {
+	mu    sync.RWMutex
+	apps  map[string]sm.AppV2 // apps indexed by id
+	order []string            // app ids in the order returned by the backend
+}
+
+// NewCatalog creates a new empty Catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{
+		apps: make(map[string]sm.AppV2),
+	}
+}
+
+// Apps returns all the apps of the catalog in the order returned by the backend.
+func (c *Catalog) Apps() []sm.AppV2 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	apps := make([]sm.AppV2, 0, len(c.order))
+	for _, id := range c.order {
+		apps = append(apps, c.apps[id])
+	}
+
+	return apps
+}
+
+// Get returns the app with the given id.
+func (c *Catalog) Get(id string) (sm.AppV2, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	app, ok := c.apps[id]
+	return app, ok
+}
+
+// Contains returns true if the app with the given id is in the catalog.
+func (c *Catalog) Contains(id string) bool {
+	_, ok := c.Get(id)
+	return ok
+}
+
+// IsEmpty returns true if the catalog has never been refreshed or has no apps.
+func (c *Catalog) IsEmpty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.order) == 0
+}
+
+// Replace replaces the catalog apps with the given apps and returns the changes.
+func (c *Catalog) Replace(apps []sm.AppV2) *model.CatalogDiff {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	diff := &model.CatalogDiff{
+		Added:   []sm.AppV2{},
+		Removed: []sm.AppV2{},
+		Changed: []sm.AppV2{},
+	}
+
+	index := make(map[string]sm.AppV2, len(apps))
+	order := make([]string, 0, len(apps))
+	for _, app := range apps {
+		id := app.Id.String()
+		if _, ok := index[id]; ok {
+			// The same app can appear twice if the catalog changes between the page requests.
+			continue
+		}
+		index[id] = app
+		order = append(order, id)
+
+		if previous, ok := c.apps[id]; !ok {
+			diff.Added = append(diff.Added, app)
+		} else if !isSameApp(previous, app) {
+			diff.Changed = append(diff.Changed, app)
+		}
+	}
+
+	for _, id := range c.order {
+		if _, ok := index[id]; !ok {
+			diff.Removed = append(diff.Removed, c.apps[id])
+		}
+	}
+
+	c.apps = index
+	c.order = order
+
+	return diff
+}
+
+// Search returns the catalog apps which name contains the query and which support the platform, empty query or platform matches all the apps.
+func (c *Catalog) Search(query string, platform string) []sm.AppV2 {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var result []sm.AppV2
+	for _, app := range c.Apps() {
+		if query != "" && !strings.Contains(strings.ToLower(app.Name), query) {
+			continue
+		}
+
+		if platform != "" && !appSupportsPlatform(app, platform) {
+			continue
+		}
+
+		result = append(result, app)
+	}
+
+	return result
+}
+
+// isSameApp compares the app metadata
+func isSameApp(a sm.AppV2, b sm.AppV2) bool {
+	aJson, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bJson, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(aJson, bJson)
+}
+
+// appSupportsPlatform returns true if any of the app releases has a file for the platform, apps indexed without the releases are already filtered by the backend and always match
+func appSupportsPlatform(app sm.AppV2, platform string) bool {
+	if app.Releases == nil || len(app.Releases.Entities) == 0 {
+		return true
+	}
+
+	for _, release := range app.Releases.Entities {
+		if release.Files == nil {
+			continue
+		}
+
+		for _, file := range release.Files.Entities {
+			if strings.EqualFold(file.Platform, platform) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SyncCatalog requests all the catalog pages from the backend, updates the local catalog and notifies the frontend about the changes
+func (l *Launcher) SyncCatalog() (*model.CatalogDiff, error) {
+	runtime.LogInfof(l.Ctx, "SyncCatalog")
+
+	if config.LauncherId == "" {
+		runtime.LogErrorf(l.Ctx, "launcher id is not set")
+		return nil, fmt.Errorf("launcher id is not set")
+	}
+	launcherId := uuid.FromStringOrNil(config.LauncherId)
+
+	var apps []sm.AppV2
+	seen := make(map[string]bool)
+	for i := 0; ; i++ {
+		if i == CatalogMaxPages {
+			runtime.LogWarningf(l.Ctx, "catalog has more than %d pages, stopping", CatalogMaxPages)
+			break
+		}
+
+		page, _, err := api.IndexLauncherApps(l.Ctx, launcherId, int64(i)*CatalogPageSize, CatalogPageSize)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to index launcher apps: %v", err)
+			return nil, fmt.Errorf("failed to index launcher apps: %w", err)
+		}
+
+		// A single app may repeat if the catalog changes between the page requests, but a page without new apps means
+		// the backend has ignored the offset or a stale cached page has been returned.
+		added := 0
+		for _, app := range page {
+			if id := app.Id.String(); !seen[id] {
+				seen[id] = true
+				added++
+			}
+		}
+		if len(page) > 0 && added == 0 {
+			runtime.LogWarningf(l.Ctx, "catalog page %d repeats the previous apps, stopping", i)
+			break
+		}
+
+		apps = append(apps, page...)
+
+		if int64(len(page)) < CatalogPageSize {
+			break
+		}
+	}
+
+	diff := l.Catalog.Replace(apps)
+	if !diff.IsEmpty() {
+		runtime.LogInfof(l.Ctx, "catalog changed: %d added, %d removed, %d changed", len(diff.Added), len(diff.Removed), len(diff.Changed))
+		l.EmitEvent(events.LauncherCatalogChanged, diff)
+	}
+
+	return diff, nil
+}
+
+// GetCatalog returns all the apps of the local catalog, syncs the catalog if it is empty
+func (l *Launcher) GetCatalog() ([]sm.AppV2, error) {
+	if l.Catalog.IsEmpty() {
+		if _, err := l.SyncCatalog(); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.Catalog.Apps(), nil
+}
+
+// SearchCatalog returns the apps of the local catalog filtered by the name and the platform
+func (l *Launcher) SearchCatalog(query string, platform string) ([]sm.AppV2, error) {
+	if l.Catalog.IsEmpty() {
+		if _, err := l.SyncCatalog(); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.Catalog.Search(query, platform), nil
+}
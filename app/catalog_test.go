@@ -0,0 +1,157 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"github.com/gofrs/uuid"
+	"reflect"
+	"testing"
+)
+
+// testApp returns the app with the id derived from the key, so the same key always gives the same app id.
+func testApp(key string, name string, platforms ...string) sm.AppV2 {
+	id := uuid.NewV5(uuid.NamespaceOID, key)
+
+	app := sm.AppV2{Name: name}
+	app.Id = &id
+
+	if len(platforms) > 0 {
+		release := sm.ReleaseV2{}
+		release.Files = &sm.FileBatch{}
+		for _, platform := range platforms {
+			file := sm.File{}
+			file.Platform = platform
+			release.Files.Entities = append(release.Files.Entities, file)
+		}
+		app.Releases = &sm.ReleaseV2Batch{Entities: []sm.ReleaseV2{release}}
+	}
+
+	return app
+}
+
+// appNames returns the names of the apps in order.
+func appNames(apps []sm.AppV2) []string {
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.Name)
+	}
+	return names
+}
+
+func TestCatalogReplace(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []sm.AppV2
+		apps     []sm.AppV2
+		order    []string
+		added    []string
+		removed  []string
+		changed  []string
+	}{
+		{
+			name:  "first sync",
+			apps:  []sm.AppV2{testApp("a", "A"), testApp("b", "B")},
+			order: []string{"A", "B"},
+			added: []string{"A", "B"},
+		},
+		{
+			name:     "unchanged",
+			previous: []sm.AppV2{testApp("a", "A"), testApp("b", "B")},
+			apps:     []sm.AppV2{testApp("a", "A"), testApp("b", "B")},
+			order:    []string{"A", "B"},
+		},
+		{
+			name:     "added, removed and changed",
+			previous: []sm.AppV2{testApp("a", "A"), testApp("b", "B"), testApp("c", "C")},
+			apps:     []sm.AppV2{testApp("c", "C2"), testApp("a", "A"), testApp("d", "D")},
+			order:    []string{"C2", "A", "D"},
+			added:    []string{"D"},
+			removed:  []string{"B"},
+			changed:  []string{"C2"},
+		},
+		{
+			name:     "duplicate across pages",
+			previous: []sm.AppV2{testApp("a", "A")},
+			apps:     []sm.AppV2{testApp("a", "A"), testApp("b", "B"), testApp("b", "B")},
+			order:    []string{"A", "B"},
+			added:    []string{"B"},
+		},
+		{
+			name:     "emptied",
+			previous: []sm.AppV2{testApp("a", "A")},
+			order:    []string{},
+			removed:  []string{"A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCatalog()
+			c.Replace(tt.previous)
+
+			diff := c.Replace(tt.apps)
+
+			if got := appNames(c.Apps()); !reflect.DeepEqual(got, tt.order) {
+				t.Errorf("Apps() = %v, want %v", got, tt.order)
+			}
+			if got := appNames(diff.Added); !reflect.DeepEqual(got, append([]string{}, tt.added...)) {
+				t.Errorf("Added = %v, want %v", got, tt.added)
+			}
+			if got := appNames(diff.Removed); !reflect.DeepEqual(got, append([]string{}, tt.removed...)) {
+				t.Errorf("Removed = %v, want %v", got, tt.removed)
+			}
+			if got := appNames(diff.Changed); !reflect.DeepEqual(got, append([]string{}, tt.changed...)) {
+				t.Errorf("Changed = %v, want %v", got, tt.changed)
+			}
+			if diff.IsEmpty() != (len(tt.added)+len(tt.removed)+len(tt.changed) == 0) {
+				t.Errorf("IsEmpty() = %v", diff.IsEmpty())
+			}
+			if c.IsEmpty() != (len(tt.order) == 0) {
+				t.Errorf("Catalog.IsEmpty() = %v, want %v", c.IsEmpty(), len(tt.order) == 0)
+			}
+		})
+	}
+}
+
+func TestCatalogGet(t *testing.T) {
+	c := NewCatalog()
+	app := testApp("a", "A")
+	c.Replace([]sm.AppV2{app})
+
+	if got, ok := c.Get(app.Id.String()); !ok || got.Name != "A" {
+		t.Errorf("Get() = %v, %v, want A", got.Name, ok)
+	}
+	if c.Contains(testApp("b", "B").Id.String()) {
+		t.Error("Contains() = true for an app not in the catalog")
+	}
+}
+
+func TestCatalogSearch(t *testing.T) {
+	c := NewCatalog()
+	c.Replace([]sm.AppV2{
+		testApp("a", "Space Race", "Win64"),
+		testApp("b", "Race Track", "Linux"),
+		testApp("c", "Puzzle"),
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		platform string
+		want     []string
+	}{
+		{name: "all", want: []string{"Space Race", "Race Track", "Puzzle"}},
+		{name: "query", query: "race", want: []string{"Space Race", "Race Track"}},
+		{name: "query trimmed", query: "  PUZZLE ", want: []string{"Puzzle"}},
+		{name: "platform", platform: "win64", want: []string{"Space Race", "Puzzle"}},
+		{name: "query and platform", query: "race", platform: "Linux", want: []string{"Race Track"}},
+		{name: "no match", query: "kart", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appNames(c.Search(tt.query, tt.platform)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q, %q) = %v, want %v", tt.query, tt.platform, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"context"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// OnCommandStartup returns the startup callback which runs the command line command without the UI and quits the launcher when the command is finished, the command result is passed to the done callback
+func (l *Launcher) OnCommandStartup(command func(l *Launcher) error, done func(err error)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		l.initialize(ctx)
+
+		go func() {
+			err := command(l)
+			if err != nil {
+				runtime.LogErrorf(l.Ctx, "command failed: %v", err)
+			}
+			done(err)
+			runtime.Quit(l.Ctx)
+		}()
+	}
+}
@@ -0,0 +1,373 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/http"
+	"games.launch.launcher/model"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// componentsFile is the file in the app installation directory keeping the selected components and their installed files.
+const componentsFile = ".components.json"
+
+// componentSelection is the persisted selection of the app optional components.
+type componentSelection struct {
+	Components map[string][]string `json:"components"` // installed file paths relative to the app installation directory indexed by the component tag
+}
+
+// parseComponentFileType returns the component tag and if the file is an archive for the optional component file types
+func parseComponentFileType(fileType string) (string, bool, bool) {
+	kind, tag, ok := strings.Cut(fileType, ":")
+	if !ok || tag == "" {
+		return "", false, false
+	}
+
+	switch kind {
+	case FileTypeReleaseComponent:
+		return tag, false, true
+	case FileTypeReleaseComponentArchive:
+		return tag, true, true
+	}
+
+	return "", false, false
+}
+
+// getReleaseComponentFiles returns the release files of the optional components indexed by the component tag
+func getReleaseComponentFiles(release sm.ReleaseV2) map[string][]*sm.File {
+	components := make(map[string][]*sm.File)
+	if release.Files == nil {
+		return components
+	}
+
+	for i, file := range release.Files.Entities {
+		if tag, _, ok := parseComponentFileType(file.Type); ok {
+			components[tag] = append(components[tag], &release.Files.Entities[i])
+		}
+	}
+
+	return components
+}
+
+// loadComponentSelection loads the selected components of the app installed in the given directory
+func loadComponentSelection(dir string) (*componentSelection, error) {
+	selection := &componentSelection{Components: make(map[string][]string)}
+	err := utils.ReadJSONFile(filepath.Join(dir, componentsFile), selection)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if selection.Components == nil {
+		selection.Components = make(map[string][]string)
+	}
+	return selection, nil
+}
+
+// saveComponentSelection saves the selected components of the app installed in the given directory
+func saveComponentSelection(dir string, selection *componentSelection) error {
+	return utils.WriteJSONFile(filepath.Join(dir, componentsFile), selection, 0644)
+}
+
+// getInstalledComponents returns the tags of the components installed in the given directory
+func getInstalledComponents(dir string) ([]string, error) {
+	selection, err := loadComponentSelection(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]string, 0, len(selection.Components))
+	for tag := range selection.Components {
+		components = append(components, tag)
+	}
+	sort.Strings(components)
+
+	return components, nil
+}
+
+// installAppComponents downloads and installs the files of the selected components of the release into the app installation directory
+func (l *Launcher) installAppComponents(app sm.AppV2, release sm.ReleaseV2, appInstallationPath string, components []string) error {
+	if len(components) == 0 {
+		return nil
+	}
+
+	selection, err := loadComponentSelection(appInstallationPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load component selection: %v", err)
+		return fmt.Errorf("failed to load component selection: %w", err)
+	}
+
+	releaseComponents := getReleaseComponentFiles(release)
+
+	tempDownloadPath := filepath.Join(filepath.Dir(appInstallationPath), ".tmp", app.Id.String()+"-components")
+
+	// Keep the storage cleanup of another launcher copy from removing the download.
+	lk, err := l.lockDir(tempDownloadPath)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
+	for _, component := range components {
+		files, ok := releaseComponents[component]
+		if !ok {
+			runtime.LogWarningf(l.Ctx, "component %s is not available in release %s", component, release.Version)
+			continue
+		}
+
+		totalSize := getReleaseFilesSize(files)
+		var completed uint64
+		var installed []string
+
+		for _, file := range files {
+			_, isArchive, _ := parseComponentFileType(file.Type)
+
+			var downloadPath string
+			if isArchive {
+				downloadPath = filepath.Join(tempDownloadPath, file.Id.String())
+			} else if file.OriginalPath != nil {
+				downloadPath = filepath.Join(tempDownloadPath, *file.OriginalPath)
+			} else {
+				runtime.LogErrorf(l.Ctx, "file %s has no original path", file.Id)
+				continue
+			}
+
+			counter := http.NewDownloadProgressTracker(totalSize, func(progress uint64, _ uint64) {
+				l.EmitEvent(events.AppComponentProgress, app, component, completed+progress, totalSize)
+			})
+			shared, err := l.downloadReleaseFile(file, downloadPath, counter)
+			if err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to download component file: %v", err)
+				return fmt.Errorf("failed to download component %s file: %w", component, err)
+			}
+
+			if file.Size != nil {
+				completed += uint64(*file.Size)
+			}
+
+			if isArchive {
+				extracted, err := utils.ExtractArchiveFiles(l.Ctx, downloadPath, appInstallationPath)
+				if err != nil {
+					runtime.LogErrorf(l.Ctx, "failed to extract component archive: %v", err)
+					return fmt.Errorf("failed to extract component %s archive: %w", component, err)
+				}
+				installed = append(installed, extracted...)
+
+				if shared {
+					l.keepPeerFile(file, downloadPath)
+				}
+			} else {
+				destinationPath := filepath.Join(appInstallationPath, *file.OriginalPath)
+				err = os.MkdirAll(filepath.Dir(destinationPath), 0755)
+				if err != nil {
+					runtime.LogErrorf(l.Ctx, "failed to create directory: %v", err)
+					return fmt.Errorf("failed to create directory: %w", err)
+				}
+
+				err = os.Rename(downloadPath, destinationPath)
+				if err != nil {
+					runtime.LogErrorf(l.Ctx, "failed to move component file: %v", err)
+					return fmt.Errorf("failed to move component %s file: %w", component, err)
+				}
+				installed = append(installed, filepath.FromSlash(*file.OriginalPath))
+
+				if shared {
+					l.addPeerFile(file, destinationPath)
+				}
+			}
+		}
+
+		selection.Components[component] = installed
+
+		// Persist the selection after each component, so the installed files are known even if a later component fails.
+		err = saveComponentSelection(appInstallationPath, selection)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to save component selection: %v", err)
+			return fmt.Errorf("failed to save component selection: %w", err)
+		}
+	}
+
+	err = os.RemoveAll(tempDownloadPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to remove temporary download directory: %v", err)
+	}
+
+	return nil
+}
+
+// removeAppComponents removes the installed files of the components from the app installation directory
+func (l *Launcher) removeAppComponents(appInstallationPath string, components []string) error {
+	selection, err := loadComponentSelection(appInstallationPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load component selection: %v", err)
+		return fmt.Errorf("failed to load component selection: %w", err)
+	}
+
+	for _, component := range components {
+		for _, path := range selection.Components[component] {
+			err = os.Remove(filepath.Join(appInstallationPath, path))
+			if err != nil && !os.IsNotExist(err) {
+				runtime.LogErrorf(l.Ctx, "failed to remove component file: %v", err)
+				return fmt.Errorf("failed to remove component %s file: %w", component, err)
+			}
+		}
+
+		delete(selection.Components, component)
+	}
+
+	return saveComponentSelection(appInstallationPath, selection)
+}
+
+// GetAppComponents returns the optional components of the latest app release and the installed components
+func (l *Launcher) GetAppComponents(id uuid.UUID) ([]model.AppComponent, error) {
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
+		return nil, err
+	}
+
+	dir, err := l.getAppInstallationDir(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app installation dir: %v", err)
+		return nil, fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+
+	selection, err := loadComponentSelection(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load component selection: %v", err)
+		return nil, fmt.Errorf("failed to load component selection: %w", err)
+	}
+
+	components := make(map[string]*model.AppComponent)
+	if app.Releases != nil && len(app.Releases.Entities) > 0 {
+		for tag, files := range getReleaseComponentFiles(app.Releases.Entities[0]) {
+			components[tag] = &model.AppComponent{
+				Id:        tag,
+				Size:      getReleaseFilesSize(files),
+				Available: true,
+			}
+		}
+	}
+
+	for tag, paths := range selection.Components {
+		component, ok := components[tag]
+		if !ok {
+			component = &model.AppComponent{Id: tag}
+			components[tag] = component
+		}
+
+		component.Installed = true
+		for _, path := range paths {
+			if fi, err := os.Stat(filepath.Join(dir, path)); err == nil {
+				component.InstalledSize += uint64(fi.Size())
+			}
+		}
+	}
+
+	result := make([]model.AppComponent, 0, len(components))
+	for _, component := range components {
+		result = append(result, *component)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+
+	return result, nil
+}
+
+// SetAppComponents installs the newly selected components of the installed app and removes the components which are no longer selected
+func (l *Launcher) SetAppComponents(id uuid.UUID, components []string) error {
+	if l.IsUpdatingApp {
+		return ErrorAppIsUpdating
+	}
+
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
+		return err
+	}
+
+	if app.Releases == nil || len(app.Releases.Entities) == 0 {
+		runtime.LogErrorf(l.Ctx, "no releases found for app %s", app.Id)
+		return fmt.Errorf("no releases found for app %s", app.Id)
+	}
+
+	installed, err := l.IsAppInstalled(id)
+	if err != nil && !os.IsNotExist(err) {
+		runtime.LogErrorf(l.Ctx, "failed to check if app is installed: %s", err)
+		return fmt.Errorf("failed to check if app is installed: %w", err)
+	}
+
+	if !installed {
+		runtime.LogWarningf(l.Ctx, "app is not installed")
+		return fmt.Errorf("app is not installed")
+	}
+
+	dir, err := l.getAppInstallationDir(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app installation dir: %v", err)
+		return fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+
+	lk, err := l.lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
+	current, err := getInstalledComponents(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get installed components: %v", err)
+		return fmt.Errorf("failed to get installed components: %w", err)
+	}
+
+	selected := make(map[string]bool, len(components))
+	for _, component := range components {
+		selected[component] = true
+	}
+
+	var removed, added []string
+	for _, component := range current {
+		if !selected[component] {
+			removed = append(removed, component)
+		}
+		delete(selected, component)
+	}
+	for _, component := range components {
+		if selected[component] {
+			added = append(added, component)
+			delete(selected, component)
+		}
+	}
+
+	err = removeAppManifest(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "%v", err)
+		return err
+	}
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	err = l.removeAppComponents(dir, removed)
+	if err != nil {
+		return err
+	}
+
+	err = l.installAppComponents(*app, app.Releases.Entities[0], dir, added)
+	if err != nil {
+		return err
+	}
+
+	l.EmitEvent(events.AppComponentsChanged, app)
+
+	return nil
+}
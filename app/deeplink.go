@@ -0,0 +1,273 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/config"
+	"games.launch.launcher/deeplink"
+	"games.launch.launcher/events"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Deep link rate limit, the links above the limit are dropped.
+const (
+	deepLinkRateLimit  = 5
+	deepLinkRateWindow = 10 * time.Second
+)
+
+var ErrorDeepLinkNotFound = errors.New("deep link not found")
+
+var deepLinkLimiter = deeplink.NewLimiter(deepLinkRateLimit, deepLinkRateWindow)
+
+// awaitingConfirmation keeps the deep links waiting for the user confirmation, indexed by the request id.
+var awaitingConfirmation sync.Map
+
+// awaitingInstall keeps the deep links requested for the apps which are not installed, indexed by the app id, the action continues once the app is installed.
+var awaitingInstall sync.Map
+
+// DeepLinkConfirmation is a deep link action waiting for the user confirmation.
+type DeepLinkConfirmation struct {
+	RequestId string         `json:"requestId"` // the id passed to ConfirmDeepLink
+	Link      *deeplink.Link `json:"link"`      // the deep link to confirm
+}
+
+// requiresConfirmation returns true for the deep link actions starting or modifying the app, the other actions only show the launcher pages
+func requiresConfirmation(action string) bool {
+	switch action {
+	case deeplink.ActionLaunch, deeplink.ActionJoin, deeplink.ActionUpdate:
+		return true
+	default:
+		return false
+	}
+}
+
+// verifyDeepLink checks the backend signature of the deep link and returns true if the link is signed, the unsigned links are rejected if the settings require the signature
+func verifyDeepLink(link *deeplink.Link, settings model.DeepLinkSettings) (bool, error) {
+	if config.DeepLinkPublicKey == "" {
+		if settings.RequireSignature {
+			return false, fmt.Errorf("deep link signature is required, but the verification key is not configured")
+		}
+		return false, nil
+	}
+
+	if !link.IsSigned() && !settings.RequireSignature {
+		return false, nil
+	}
+
+	publicKey, err := deeplink.ParsePublicKey(config.DeepLinkPublicKey)
+	if err != nil {
+		return false, err
+	}
+
+	err = link.Verify(publicKey, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isDeepLinkAllowed returns true if the user has allowed the deep link action of the app
+func isDeepLinkAllowed(link *deeplink.Link, settings model.AppSettings) bool {
+	if settings.AllowDeepLinks {
+		return true
+	}
+
+	for _, action := range settings.AllowedDeepLinks {
+		if action == link.Action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorizeDeepLink applies the deep link policy: the links above the rate limit and the links with an invalid signature are dropped,
+// the actions starting the app are confirmed by the user the first time unless the link is signed by the backend or the app is always allowed
+func (l *Launcher) authorizeDeepLink(link *deeplink.Link) {
+	if !deepLinkLimiter.Allow(time.Now()) {
+		runtime.LogWarningf(l.Ctx, "deep link rate limit exceeded, dropping %s", link.Url)
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to load settings: %v", err)
+	}
+
+	signed, err := verifyDeepLink(link, settings.DeepLinks)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "rejected deep link %s: %v", link.Url, err)
+		return
+	}
+
+	if signed || !requiresConfirmation(link.Action) || isDeepLinkAllowed(link, settings.Apps[link.AppId]) {
+		l.routeDeepLink(link)
+		return
+	}
+
+	requestId, err := uuid.NewV4()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to generate request id: %v", err)
+		return
+	}
+
+	confirmation := DeepLinkConfirmation{RequestId: requestId.String(), Link: link}
+	awaitingConfirmation.Store(confirmation.RequestId, confirmation)
+
+	runtime.LogInfof(l.Ctx, "deep link action %s of app %s requires confirmation", link.Action, link.AppId)
+	l.showWindow()
+	l.EmitEvent(events.DeepLinkConfirmationRequested, confirmation)
+}
+
+// GetDeepLinkConfirmations returns the deep links waiting for the user confirmation, e.g. the deep link the launcher has been started with
+func (l *Launcher) GetDeepLinkConfirmations() []DeepLinkConfirmation {
+	var confirmations []DeepLinkConfirmation
+	awaitingConfirmation.Range(func(_, confirmation any) bool {
+		confirmations = append(confirmations, confirmation.(DeepLinkConfirmation))
+		return true
+	})
+
+	sort.Slice(confirmations, func(i, j int) bool {
+		return confirmations[i].RequestId < confirmations[j].RequestId
+	})
+
+	return confirmations
+}
+
+// ConfirmDeepLink performs the deep link action allowed by the user and remembers the action of the app, or all its actions if always is set, so they are not confirmed again
+func (l *Launcher) ConfirmDeepLink(requestId string, allow bool, always bool) error {
+	value, ok := awaitingConfirmation.LoadAndDelete(requestId)
+	if !ok {
+		return ErrorDeepLinkNotFound
+	}
+
+	link := value.(DeepLinkConfirmation).Link
+	if !allow {
+		runtime.LogInfof(l.Ctx, "deep link action %s of app %s declined", link.Action, link.AppId)
+		return nil
+	}
+
+	id := uuid.FromStringOrNil(link.AppId)
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return err
+	}
+
+	if always {
+		settings.AllowDeepLinks = true
+	} else if !isDeepLinkAllowed(link, settings) {
+		settings.AllowedDeepLinks = append(settings.AllowedDeepLinks, link.Action)
+	}
+
+	err = l.SetAppSettings(id, settings)
+	if err != nil {
+		return err
+	}
+
+	go l.routeDeepLink(link)
+
+	return nil
+}
+
+// routeDeepLink performs the deep link action, the installation is offered first if the app is not installed
+func (l *Launcher) routeDeepLink(link *deeplink.Link) {
+	if link.Action == deeplink.ActionOpenPage {
+		l.navigateDeepLink(link.Page, link.AppId)
+		return
+	}
+
+	id := uuid.FromStringOrNil(link.AppId)
+	installed, err := l.IsAppInstalled(id)
+	if err != nil && !os.IsNotExist(err) {
+		runtime.LogErrorf(l.Ctx, "failed to check if app is installed: %v", err)
+		return
+	}
+
+	if !installed {
+		runtime.LogInfof(l.Ctx, "app %s is not installed, offering installation for deep link action %s", id, link.Action)
+		awaitingInstall.Store(link.AppId, link)
+		l.showWindow()
+		l.EmitEvent(events.DeepLinkInstallOffered, link)
+		return
+	}
+
+	l.continueDeepLink(link)
+}
+
+// continueDeepLink performs the deep link action for the installed app
+func (l *Launcher) continueDeepLink(link *deeplink.Link) {
+	id := uuid.FromStringOrNil(link.AppId)
+
+	switch link.Action {
+	case deeplink.ActionLaunch, deeplink.ActionJoin:
+		l.launchDeepLink(link)
+	case deeplink.ActionUpdate:
+		l.navigateDeepLink(deeplink.PageApp, link.AppId)
+
+		// The update progress is shown on the app page.
+		go func() {
+			availability, err := l.CheckForAppUpdates(id)
+			if err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to check for app updates: %v", err)
+				return
+			}
+
+			if availability == UpdateAvailabilityAvailable {
+				err = l.UpdateApp(id)
+				if err != nil {
+					runtime.LogErrorf(l.Ctx, "failed to update app: %v", err)
+				}
+			}
+		}()
+	default:
+		// The installed app page is shown for the install and open actions.
+		l.navigateDeepLink(deeplink.PageApp, link.AppId)
+	}
+}
+
+// resumeDeepLink continues the deep link action waiting for the app installation
+func (l *Launcher) resumeDeepLink(id uuid.UUID) {
+	if link, ok := awaitingInstall.LoadAndDelete(id.String()); ok {
+		runtime.LogInfof(l.Ctx, "app %s installed, continuing deep link action %s", id, link.(*deeplink.Link).Action)
+		go l.continueDeepLink(link.(*deeplink.Link))
+	}
+}
+
+// navigateDeepLink brings the launcher window to the front and asks the frontend to show the page
+func (l *Launcher) navigateDeepLink(page string, appId string) {
+	l.showWindow()
+	l.EmitEvent(events.DeepLinkNavigate, page, appId)
+}
+
+// showWindow brings the launcher window to the front
+func (l *Launcher) showWindow() {
+	runtime.WindowUnminimise(l.Ctx)
+	runtime.WindowShow(l.Ctx)
+}
+
+// GetDeepLinkOffers returns the deep links waiting for the installation of their apps, e.g. the deep link the launcher has been started with
+func (l *Launcher) GetDeepLinkOffers() []*deeplink.Link {
+	var links []*deeplink.Link
+	awaitingInstall.Range(func(_, link any) bool {
+		links = append(links, link.(*deeplink.Link))
+		return true
+	})
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].AppId < links[j].AppId
+	})
+
+	return links
+}
+
+// DismissDeepLink drops the deep link waiting for the app installation, e.g. when the user declines the installation
+func (l *Launcher) DismissDeepLink(id uuid.UUID) {
+	awaitingInstall.Delete(id.String())
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"games.launch.launcher/config"
+	"games.launch.launcher/deeplink"
+	"games.launch.launcher/model"
+	"testing"
+)
+
+const testAppId = "6f0b1a3e-2c4d-4e5f-8a9b-0c1d2e3f4a5b"
+
+func TestRequiresConfirmation(t *testing.T) {
+	tests := []struct {
+		action string
+		want   bool
+	}{
+		{action: deeplink.ActionLaunch, want: true},
+		{action: deeplink.ActionJoin, want: true},
+		{action: deeplink.ActionUpdate, want: true},
+		{action: deeplink.ActionInstall, want: false},
+		{action: deeplink.ActionOpen, want: false},
+		{action: deeplink.ActionOpenPage, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			if got := requiresConfirmation(tt.action); got != tt.want {
+				t.Errorf("requiresConfirmation(%q) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDeepLinkAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		settings model.AppSettings
+		want     bool
+	}{
+		{name: "not allowed", action: deeplink.ActionLaunch, want: false},
+		{name: "all allowed", action: deeplink.ActionLaunch, settings: model.AppSettings{AllowDeepLinks: true}, want: true},
+		{name: "action allowed", action: deeplink.ActionJoin, settings: model.AppSettings{AllowedDeepLinks: []string{deeplink.ActionJoin}}, want: true},
+		{name: "other action allowed", action: deeplink.ActionLaunch, settings: model.AppSettings{AllowedDeepLinks: []string{deeplink.ActionJoin}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &deeplink.Link{Action: tt.action, AppId: testAppId}
+			if got := isDeepLinkAllowed(link, tt.settings); got != tt.want {
+				t.Errorf("isDeepLinkAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyDeepLinkWithoutKey(t *testing.T) {
+	key := config.DeepLinkPublicKey
+	config.DeepLinkPublicKey = ""
+	defer func() {
+		config.DeepLinkPublicKey = key
+	}()
+
+	link, err := deeplink.Parse("le7el://launch?appId=" + testAppId)
+	if err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		settings model.DeepLinkSettings
+		err      bool
+	}{
+		{name: "signature optional", settings: model.DeepLinkSettings{}},
+		{name: "signature required", settings: model.DeepLinkSettings{RequireSignature: true}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := verifyDeepLink(link, tt.settings)
+			if signed {
+				t.Errorf("verifyDeepLink() signed = true without the verification key")
+			}
+			if (err != nil) != tt.err {
+				t.Errorf("verifyDeepLink() error = %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/manifest"
+	"games.launch.launcher/model"
+	"games.launch.launcher/version"
+	"github.com/Masterminds/semver"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrorUnknownInstallation = errors.New("failed to identify the app installed in the directory")
+var ErrorInstallationVerificationFailed = errors.New("installed files verification failed")
+
+// identifyInstallation identifies the app installed in the given directory using the integrity manifest, the given id or the directory name
+func (l *Launcher) identifyInstallation(dir string, id uuid.UUID) (*model.ExistingInstallation, *manifest.Manifest, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat installation dir: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	installation := &model.ExistingInstallation{Path: dir}
+
+	m, err := manifest.Read(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		runtime.LogWarningf(l.Ctx, "failed to read manifest: %v", err)
+	}
+	installation.HasManifest = m != nil
+
+	switch {
+	case m != nil && m.AppId != "":
+		manifestId, err := uuid.FromString(m.AppId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse manifest app id: %w", err)
+		}
+		if !id.IsNil() && id != manifestId {
+			return nil, nil, fmt.Errorf("the directory contains app %s, not %s", manifestId, id)
+		}
+		id = manifestId
+	case id.IsNil():
+		// Apps are installed into the directories named by the app id.
+		id, err = uuid.FromString(filepath.Base(dir))
+		if err != nil {
+			return nil, nil, ErrorUnknownInstallation
+		}
+	}
+	installation.Id = id.String()
+
+	installed, err := getInstalledVersion(dir)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to read installed version: %v", err)
+	}
+	if installed != nil {
+		installation.Version = installed.String()
+	} else if m != nil {
+		installation.Version = m.Version
+	}
+
+	installation.Name, err = l.getAppName(id)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to get app metadata: %v", err)
+	}
+
+	return installation, m, nil
+}
+
+// verifyInstallation checks the files against the integrity manifest, the directory without a manifest must contain the app executable
+func (l *Launcher) verifyInstallation(installation *model.ExistingInstallation, m *manifest.Manifest) error {
+	id := uuid.FromStringOrNil(installation.Id)
+
+	if m != nil {
+		invalid, err := manifest.Verify(installation.Path, m, func(progress uint64, total uint64) {
+			l.EmitEvent(events.AppVerifyProgress, installation.Id, progress, total)
+		})
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to verify installed files: %v", err)
+			return fmt.Errorf("failed to verify installed files: %w", err)
+		}
+
+		installation.InvalidFiles = invalid
+		if len(invalid) > 0 {
+			runtime.LogErrorf(l.Ctx, "installed files verification failed: %s", strings.Join(invalid, ", "))
+			return fmt.Errorf("%w: %s", ErrorInstallationVerificationFailed, strings.Join(invalid, ", "))
+		}
+
+		return nil
+	}
+
+	_, err := l.findAppExecutable(installation.Path, id, installation.Name)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to find app executable: %v", err)
+		return fmt.Errorf("%w: %v", ErrorInstallationVerificationFailed, err)
+	}
+
+	return nil
+}
+
+// InspectExistingInstallation identifies and verifies the app installed in the given directory without registering it, the id may be empty if the directory contains the integrity manifest or is named by the app id
+func (l *Launcher) InspectExistingInstallation(dir string, id uuid.UUID) (*model.ExistingInstallation, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	installation, m, err := l.identifyInstallation(dir, id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to identify installation: %v", err)
+		return nil, err
+	}
+
+	err = l.verifyInstallation(installation, m)
+	if err != nil && !errors.Is(err, ErrorInstallationVerificationFailed) {
+		return nil, err
+	}
+
+	return installation, nil
+}
+
+// AddExistingInstallation identifies and verifies the app installed in the given directory and registers the directory as the app installation without downloading the app
+func (l *Launcher) AddExistingInstallation(dir string, id uuid.UUID) (*model.ExistingInstallation, error) {
+	if l.IsUpdatingApp {
+		return nil, ErrorAppIsUpdating
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	installation, m, err := l.identifyInstallation(dir, id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to identify installation: %v", err)
+		return nil, err
+	}
+	id = uuid.FromStringOrNil(installation.Id)
+
+	current, err := l.getAppInstallationDir(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+	if filepath.Clean(current) != filepath.Clean(dir) {
+		if _, err = os.Stat(current); err == nil {
+			runtime.LogErrorf(l.Ctx, "app %s is already installed at %s", id, current)
+			return nil, ErrorAppInstalled
+		}
+	}
+
+	lk, err := l.lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer l.unlockDir(lk)
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	err = l.verifyInstallation(installation, m)
+	if err != nil {
+		l.EmitEvent(events.AppVerifyFailed, installation.Id, err.Error())
+		return installation, err
+	}
+
+	// Restore the version file from the manifest, so the app updates start from the installed version.
+	if _, err = os.Stat(filepath.Join(dir, ".version")); os.IsNotExist(err) && installation.Version != "" {
+		if v, err := semver.NewVersion(installation.Version); err == nil {
+			err = version.WriteVersion(dir, v)
+			if err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to write version: %v", err)
+			}
+		}
+	}
+
+	// Write the manifest of the verified files, so the installation can be verified later.
+	if m == nil {
+		m, err = manifest.Build(dir, func(progress uint64, total uint64) {
+			l.EmitEvent(events.AppVerifyProgress, installation.Id, progress, total)
+		})
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to build manifest: %v", err)
+		} else {
+			m.AppId = installation.Id
+			m.Version = installation.Version
+			err = manifest.Write(dir, m)
+			if err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to write manifest: %v", err)
+			} else {
+				installation.HasManifest = true
+			}
+		}
+	}
+
+	err = addLibrary(filepath.Dir(dir))
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to add library: %v", err)
+		return nil, err
+	}
+
+	err = setAppInstallationDir(id, dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to register app installation: %v", err)
+		return nil, err
+	}
+
+	l.EmitEvent(events.AppInstallationAdded, installation.Id, dir)
+
+	return installation, nil
+}
+
+// removeAppManifest removes the integrity manifest from the app installation directory before the installed files change, so the installation is never verified against the hashes of the previous files
+func removeAppManifest(dir string) error {
+	err := os.Remove(filepath.Join(dir, manifest.FileName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove manifest: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/manifest"
+	"games.launch.launcher/version"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PackageMetadataFile is the app metadata file embedded into the exported app packages.
+const PackageMetadataFile = ".metadata.json"
+
+// getExportMetadata returns the app metadata with the installed release only, the metadata is built from the version file if the app metadata is not available offline
+func (l *Launcher) getExportMetadata(id uuid.UUID, record *version.Record) sm.AppV2 {
+	app := sm.AppV2{}
+	if metadata, err := l.GetAppMetadata(id); err == nil && metadata != nil {
+		app = *metadata
+	} else {
+		runtime.LogWarningf(l.Ctx, "failed to get app metadata, exporting without it: %v", err)
+	}
+	app.Id = &id
+
+	release := sm.ReleaseV2{Version: record.Version.String()}
+	if app.Releases != nil {
+		for _, r := range app.Releases.Entities {
+			if r.Version == release.Version {
+				release = r
+				break
+			}
+		}
+	}
+	if release.CodeVersion == "" && record.CodeVersion != nil {
+		release.CodeVersion = record.CodeVersion.String()
+	}
+	if release.ContentVersion == "" && record.ContentVersion != nil {
+		release.ContentVersion = record.ContentVersion.String()
+	}
+
+	app.Releases = &sm.ReleaseV2Batch{Entities: []sm.ReleaseV2{release}}
+
+	return app
+}
+
+// ExportApp exports the installed app into a portable zip package with the app files, the version, the app metadata and the integrity manifest, the package is installed by ImportApp on another machine
+func (l *Launcher) ExportApp(id uuid.UUID, packagePath string) error {
+	if l.IsUpdatingApp {
+		return ErrorAppIsUpdating
+	}
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	err := l.exportApp(id, packagePath)
+	if err != nil {
+		l.EmitEvent(events.AppExportFailed, id.String(), err.Error())
+		return err
+	}
+
+	l.EmitEvent(events.AppExportCompleted, id.String(), packagePath)
+
+	return nil
+}
+
+// exportApp writes the app package, the package is written to a temporary file first, so an interrupted export never leaves a partial package
+func (l *Launcher) exportApp(id uuid.UUID, packagePath string) error {
+	dir, err := l.getAppInstallationDir(id)
+	if err != nil {
+		return fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+
+	if _, err = os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return ErrorAppNotInstalled
+		}
+		return fmt.Errorf("failed to stat app installation dir: %w", err)
+	}
+
+	lk, err := l.lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
+	record, err := version.ReadRecord(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to read app version: %v", err)
+		return fmt.Errorf("failed to read app version: %w", err)
+	}
+
+	app := l.getExportMetadata(id, record)
+
+	files, total, err := listMoveFiles(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to list app files: %v", err)
+		return fmt.Errorf("failed to list app files: %w", err)
+	}
+
+	tmpPath := packagePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to create package: %v", err)
+		return fmt.Errorf("failed to create package: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	w := zip.NewWriter(f)
+
+	m := &manifest.Manifest{AppId: id.String(), Version: record.Version.String()}
+
+	var completed uint64
+	for _, file := range files {
+		if file == PackageMetadataFile {
+			continue
+		}
+
+		hash, size, err := addPackageFile(w, filepath.Join(dir, filepath.FromSlash(file)), file, func(n uint64) {
+			completed += n
+			l.EmitEvent(events.AppExportProgress, id.String(), completed, total)
+		})
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to add file to package: %v", err)
+			return fmt.Errorf("failed to add file %s to package: %w", file, err)
+		}
+
+		if !manifest.IsIgnored(file) {
+			m.Files = append(m.Files, manifest.File{Path: file, Size: size, Hash: hash})
+		}
+	}
+
+	err = addPackageJSON(w, manifest.FileName, m)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to add manifest to package: %v", err)
+		return fmt.Errorf("failed to add manifest to package: %w", err)
+	}
+
+	err = addPackageJSON(w, PackageMetadataFile, app)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to add metadata to package: %v", err)
+		return fmt.Errorf("failed to add metadata to package: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to write package: %v", err)
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to close package: %v", err)
+		return fmt.Errorf("failed to close package: %w", err)
+	}
+
+	err = os.Rename(tmpPath, packagePath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to move package: %v", err)
+		return fmt.Errorf("failed to move package: %w", err)
+	}
+
+	return nil
+}
+
+// addPackageFile stores the file in the package and returns its SHA-256 hash and size, the game content is compressed already, so the files are stored without compression to keep the export fast
+func addPackageFile(w *zip.Writer, path string, name string, progress func(n uint64)) (string, int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	header, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file header: %w", err)
+	}
+	header.Name = name
+	header.Method = zip.Store
+
+	dst, err := w.CreateHeader(header)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dst, h, progressFunc(progress)), src)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// addPackageJSON stores the value encoded as JSON in the package
+func addPackageJSON(w *zip.Writer, name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dst, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = dst.Write(b)
+	return err
+}
+
+// progressFunc is an io.Writer reporting the number of written bytes to the function
+type progressFunc func(n uint64)
+
+// Write implements the io.Writer interface, triggering the progress callback when data is written
+func (p progressFunc) Write(b []byte) (int, error) {
+	p(uint64(len(b)))
+	return len(b), nil
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/game"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"sort"
+	"sync"
+)
+
+var ErrorGameNotConnected = errors.New("game client is not connected")
+
+// GameClients keeps track of the game clients connected to the game channel, an app may have several clients, e.g. when testing multiple instances.
+type GameClients struct {
+	mu       sync.Mutex
+	sessions map[string]*game.Session   // sessions indexed by the connection id
+	apps     map[string][]*game.Session // sessions indexed by the app id in the connection order
+}
+
+// NewGameClients creates a new empty GameClients.
+func NewGameClients() *GameClients {
+	return &GameClients{
+		sessions: make(map[string]*game.Session),
+		apps:     make(map[string][]*game.Session),
+	}
+}
+
+// add starts tracking the connected game client.
+func (c *GameClients) add(session *game.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client := session.Client()
+	c.sessions[client.Id] = session
+	c.apps[client.AppId] = append(c.apps[client.AppId], session)
+}
+
+// remove stops tracking the disconnected game client.
+func (c *GameClients) remove(session *game.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client := session.Client()
+	delete(c.sessions, client.Id)
+
+	sessions := c.apps[client.AppId][:0]
+	for _, connected := range c.apps[client.AppId] {
+		if connected != session {
+			sessions = append(sessions, connected)
+		}
+	}
+
+	if len(sessions) == 0 {
+		delete(c.apps, client.AppId)
+	} else {
+		c.apps[client.AppId] = sessions
+	}
+}
+
+// Get returns the game client with the given connection id.
+func (c *GameClients) Get(id string) (*game.Session, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session, ok := c.sessions[id]
+	return session, ok
+}
+
+// ForApp returns the game clients of the app with the given id in the connection order.
+func (c *GameClients) ForApp(appId string) []*game.Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*game.Session(nil), c.apps[appId]...)
+}
+
+// Each calls the function for every connected game client, e.g. to broadcast a message, the function is called without holding the lock.
+func (c *GameClients) Each(f func(session *game.Session)) {
+	c.mu.Lock()
+	sessions := make([]*game.Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		sessions = append(sessions, session)
+	}
+	c.mu.Unlock()
+
+	for _, session := range sessions {
+		f(session)
+	}
+}
+
+// Clients returns the state of the connected game clients ordered by the connection time.
+func (c *GameClients) Clients() []model.GameClient {
+	clients := make([]model.GameClient, 0)
+	c.Each(func(session *game.Session) {
+		clients = append(clients, session.Client())
+	})
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+	})
+
+	return clients
+}
+
+// sendGameMessage sends the message to the game clients, the legacy game clients only receive the deep links
+func (l *Launcher) sendGameMessage(sessions []*game.Session, message game.Message) error {
+	sent := 0
+	for _, session := range sessions {
+		if session.IsLegacy() {
+			continue
+		}
+
+		err := session.Send(message)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to send %s message to game client: %v", message.Type, err)
+			return fmt.Errorf("failed to send %s message to game client: %w", message.Type, err)
+		}
+		sent++
+	}
+
+	if sent == 0 {
+		return ErrorGameNotConnected
+	}
+
+	return nil
+}
+
+// broadcastGameMessage sends the message to all the connected game clients except the legacy ones, the failures are logged
+func (l *Launcher) broadcastGameMessage(message game.Message) {
+	l.GameClients.Each(func(session *game.Session) {
+		if session.IsLegacy() {
+			return
+		}
+
+		err := session.Send(message)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to send %s message to game client %s: %v", message.Type, session.Client().Id, err)
+		}
+	})
+}
+
+// GetConnectedGameClients returns the game clients connected to the launcher ordered by the connection time
+func (l *Launcher) GetConnectedGameClients() []model.GameClient {
+	return l.GameClients.Clients()
+}
+
+// RequestGameClientShutdown asks the game client with the given connection id to quit, the game shows the reason to the user
+func (l *Launcher) RequestGameClientShutdown(connectionId string, reason string) error {
+	session, ok := l.GameClients.Get(connectionId)
+	if !ok {
+		return ErrorGameNotConnected
+	}
+
+	return l.sendGameMessage([]*game.Session{session}, game.Message{Type: game.TypeShutdown, Reason: reason})
+}
+
+// RequestAppShutdown asks the connected game clients of the app to quit, the game shows the reason to the user, the exit is reported with the app-exited event
+func (l *Launcher) RequestAppShutdown(id uuid.UUID, reason string) error {
+	return l.sendGameMessage(l.GameClients.ForApp(id.String()), game.Message{Type: game.TypeShutdown, Reason: reason})
+}
+
+// RequestGameStatus asks the connected game clients of the app to report their status, the status is reported with the game-client-status event
+func (l *Launcher) RequestGameStatus(id uuid.UUID) error {
+	return l.sendGameMessage(l.GameClients.ForApp(id.String()), game.Message{Type: game.TypeStatus})
+}
@@ -1,27 +1,106 @@
 package app
 
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"games.launch.launcher/config"
+	"games.launch.launcher/deeplink"
+	"games.launch.launcher/events"
+	"games.launch.launcher/game"
+	"games.launch.launcher/ipc"
 	ll "games.launch.launcher/logger"
+	"games.launch.launcher/transport"
 	"github.com/gofrs/uuid"
-	"io"
 	"net"
-	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var gameConnPool = make(map[string]*net.Conn)
+// pendingDeepLinks keeps the deep links of the launched apps until the apps connect, indexed by the app id.
+var pendingDeepLinks sync.Map
+
+// instanceCommandTimeout is the time a subsequent instance has to send the command and read the response.
+const instanceCommandTimeout = 30 * time.Second
+
+// Endpoint names of the launcher instance and the game client channels.
+const (
+	instanceEndpoint = "launcher"
+	gameEndpoint     = "game"
+)
+
+// instanceSuffix is appended to the endpoint names of a separate launcher instance, see SetSeparateInstance.
+var instanceSuffix string
+
+// separateInstanceRegex matches the valid separate instance names, the name becomes a part of the directory, socket and pipe names.
+var separateInstanceRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// SetSeparateInstance makes the launcher use the config directory and the endpoints of the named separate instance, so several launchers run side by side on one machine.
+func SetSeparateInstance(name string) error {
+	if !separateInstanceRegex.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q, only letters, digits, - and _ are allowed", name)
+	}
+
+	ConfigDir += "-" + name
+	instanceSuffix = "-" + name
+
+	return nil
+}
+
+// getEndpointName returns the name of the endpoint of this launcher instance.
+func getEndpointName(name string) string {
+	return name + instanceSuffix
+}
+
+// GameEnvPrefix is the prefix of the environment variables passed by the launcher to the app for the game client channel, the launch profiles can not set them.
+const GameEnvPrefix = "LE7EL_GAME_"
+
+// GameEndpointEnv is the environment variable used to pass the game client channel endpoint address to the app, e.g. unix:/run/user/1000/le7el-game.sock.
+const GameEndpointEnv = GameEnvPrefix + "ENDPOINT"
+
+// GameSecretEnv is the environment variable used to pass the secret of the launch to the app, the game presents it in the hello to receive the session token.
+const GameSecretEnv = GameEnvPrefix + "SECRET"
+
+// gameEndpointAddress is the address of the game client channel endpoint passed to the launched apps.
+var gameEndpointAddress atomic.Value
+
+// ReadInstanceToken reads the secret token created by the main instance, the subsequent instances send it with the commands.
+func ReadInstanceToken() (string, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return ipc.ReadToken(dir)
+}
+
+// DialFirstInstance connects to the main instance, failure means there is no main instance running.
+func DialFirstInstance(timeout time.Duration) (net.Conn, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.Dial(getEndpointName(instanceEndpoint), dir, timeout)
+}
 
 // Start the main instance of the application.
 func (l *Launcher) StartFirstInstance() {
-	// Start a TCP listener on the launcher designated port.
-	listener, err := net.Listen("tcp", "127.0.0.1:"+config.LauncherPort)
+	dir, err := getConfigDir()
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error getting config dir: %v\n", err))
+		return
+	}
+
+	// Start listening on the launcher endpoint accessible by the user only.
+	listener, address, err := transport.Listen(getEndpointName(instanceEndpoint), dir)
 	if err != nil {
 		ll.Logger.Error(fmt.Sprintf("error starting listener: %v\n", err))
 		return
 	}
+	ll.Logger.Print(fmt.Sprintf("Listening for subsequent instances on %s\n", address))
 
 	// Close the listener when the function exits.
 	defer func(listener net.Listener) {
@@ -31,22 +110,32 @@ func (l *Launcher) StartFirstInstance() {
 		}
 	}(listener)
 
+	// Create a new secret token for this session, only the processes of the user able to read it can send the commands.
+	token, err := ipc.CreateToken(dir)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error creating instance token: %v\n", err))
+		return
+	}
+
 	// Listen for subsequent instance connections, function runs as a goroutine, so it will not block.
 	for {
 		// Accept incoming connections.
 		conn, err := listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 			ll.Logger.Error(fmt.Sprintf("Error accepting connection: %v\n", err))
 			continue
 		}
 
 		// Handle the connection with a subsequent instance in a goroutine.
-		go l.handleSubsequentLauncherInstanceConnection(conn)
+		go l.handleSubsequentLauncherInstanceConnection(conn, token)
 	}
 }
 
 // Handle a connection from a subsequent instance of the application.
-func (l *Launcher) handleSubsequentLauncherInstanceConnection(conn net.Conn) {
+func (l *Launcher) handleSubsequentLauncherInstanceConnection(conn net.Conn, token string) {
 	// Close the connection when the function exits.
 	defer func(conn net.Conn) {
 		err := conn.Close()
@@ -55,19 +144,90 @@ func (l *Launcher) handleSubsequentLauncherInstanceConnection(conn net.Conn) {
 		}
 	}(conn)
 
-	// Read the parameters from the subsequent launcher connection.
-	data, err := io.ReadAll(conn)
+	err := conn.SetDeadline(time.Now().Add(instanceCommandTimeout))
 	if err != nil {
-		ll.Logger.Error(fmt.Sprintf("Error reading data: %v\n", err))
+		ll.Logger.Error(fmt.Sprintf("Error setting connection deadline: %v\n", err))
 		return
 	}
 
-	// Convert the deep link to a string.
-	deepLink := string(data)
-	ll.Logger.Print(fmt.Sprintf("Received deep link: %s\n", deepLink))
+	// Read the command from the subsequent launcher connection.
+	var request ipc.Request
+	err = ipc.ReadFrame(conn, &request)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error reading command: %v\n", err))
+		return
+	}
+
+	// Execute the command if the subsequent instance has sent the valid token.
+	var response ipc.Response
+	if err = request.Authorize(token); err != nil {
+		ll.Logger.Error(fmt.Sprintf("Rejected %s command: %v\n", request.Command, err))
+		response = ipc.Fail(err)
+	} else {
+		ll.Logger.Print(fmt.Sprintf("Received command: %s %v\n", request.Command, request.Arguments))
+		response = l.handleInstanceCommand(request)
+	}
+
+	// Send the response printed by the subsequent instance.
+	err = ipc.WriteFrame(conn, response)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error sending response: %v\n", err))
+	}
+}
+
+// Execute the command sent by a subsequent instance.
+func (l *Launcher) handleInstanceCommand(request ipc.Request) ipc.Response {
+	var argument string
+	if len(request.Arguments) > 0 {
+		argument = request.Arguments[0]
+	}
+
+	switch request.Command {
+	case ipc.CommandPing:
+		return ipc.Ok("launcher is running", nil)
+	case ipc.CommandShow:
+		l.showWindow()
+		return ipc.Ok("launcher window shown", nil)
+	case ipc.CommandDeepLink:
+		// Parse the deep link first, so the subsequent instance reports the invalid links.
+		link, err := deeplink.Parse(argument)
+		if err != nil {
+			return ipc.Fail(err)
+		}
+
+		go l.authorizeDeepLink(link)
+		return ipc.Ok("deep link accepted", nil)
+	case ipc.CommandLaunch:
+		id, err := uuid.FromString(argument)
+		if err != nil {
+			return ipc.Fail(fmt.Errorf("invalid app id: %w", err))
+		}
+
+		if err = l.LaunchApp(id); err != nil {
+			return ipc.Fail(err)
+		}
+		return ipc.Ok(fmt.Sprintf("app %s launched", id), nil)
+	case ipc.CommandStop:
+		id, err := uuid.FromString(argument)
+		if err != nil {
+			return ipc.Fail(fmt.Errorf("invalid app id: %w", err))
+		}
+
+		if err = l.StopApp(id); err != nil {
+			return ipc.Fail(err)
+		}
+		return ipc.Ok(fmt.Sprintf("app %s stopped", id), nil)
+	case ipc.CommandRunning:
+		running := l.GetRunningApps()
 
-	// Process the deep link.
-	l.processDeepLink(deepLink)
+		lines := make([]string, 0, len(running))
+		for _, app := range running {
+			lines = append(lines, fmt.Sprintf("%s\t%d\t%s", app.Id, app.Pid, app.StartedAt.Format(time.RFC3339)))
+		}
+		return ipc.Ok(strings.Join(lines, "\n"), running)
+	default:
+		return ipc.Fail(fmt.Errorf("unknown command %q", request.Command))
+	}
 }
 
 // Do a deep link processing task.
@@ -75,53 +235,83 @@ func (l *Launcher) processDeepLink(deepLink string) {
 	// Process the deep link as needed.
 	ll.Logger.Print(fmt.Sprintf("Processing deep link: %s\n", deepLink))
 
-	// Parse the deep link from the URL.
-	parsedUrl, err := url.Parse(deepLink)
+	// Parse and validate the deep link, the invalid links are ignored.
+	link, err := deeplink.Parse(deepLink)
 	if err != nil {
 		ll.Logger.Error(fmt.Sprintf("Error parsing deep link: %v\n", err))
 		return
 	}
 
-	// Get the app id from the deep link.
-	queryParams := parsedUrl.Query()
-	appId := queryParams.Get("appId")
-	if appId == "" {
-		ll.Logger.Error(fmt.Sprintf("Error parsing app id: %s\n", appId))
-		return
-	}
+	l.authorizeDeepLink(link)
+}
 
-	// Check if the game client is already running.
-	if gameConn, ok := gameConnPool[appId]; ok {
-		_, err := (*gameConn).Write([]byte(deepLink))
+// Launch the app requested by the deep link, or pass the deep link to the app if it is already connected.
+func (l *Launcher) launchDeepLink(link *deeplink.Link) {
+	// Check if the game client is already running, the most recently connected client of the app receives the deep link.
+	if sessions := l.GameClients.ForApp(link.AppId); len(sessions) > 0 {
+		err := sessions[len(sessions)-1].Send(link.Message())
 		if err != nil {
 			ll.Logger.Error(fmt.Sprintf("Error sending deep link: %v\n", err))
-			return
-		}
-	} else {
-		appUuid := uuid.FromStringOrNil(appId)
-
-		if appUuid.IsNil() {
-			ll.Logger.Error(fmt.Sprintf("Error parsing app id: %s\n", appId))
-		} else {
-			err := l.LaunchApp(appUuid)
-			if err != nil {
-				ll.Logger.Error(fmt.Sprintf("Error launching app: %v\n", err))
-				return
-			}
 		}
+		return
+	}
+
+	// The game receives the deep link once it connects, the arguments take it to the destination while loading.
+	pendingDeepLinks.Store(link.AppId, link)
+
+	err := l.launchAppWithArguments(uuid.FromStringOrNil(link.AppId), link.Arguments())
+	if err != nil {
+		pendingDeepLinks.Delete(link.AppId)
+		ll.Logger.Error(fmt.Sprintf("Error launching app: %v\n", err))
+		return
 	}
 }
 
-// Start the game client listening TCP server.
+// Start the game client listeners.
 func (l *Launcher) StartGameClientListener() {
+	dir, err := getConfigDir()
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error getting config dir: %v\n", err))
+		return
+	}
+
+	// Start listening on the game endpoint, the launched apps receive its address in the environment.
+	listener, address, err := transport.Listen(getEndpointName(gameEndpoint), dir)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error starting game endpoint listener: %v\n", err))
+	} else {
+		ll.Logger.Print(fmt.Sprintf("Listening for game clients on %s\n", address))
+		gameEndpointAddress.Store(address)
+		// The TCP fallback is reachable by any local process, so its clients are never trusted with the session token.
+		go l.acceptGameClients(listener, !strings.HasPrefix(address, transport.SchemeTcp+":"))
+	}
+
+	// The designated TCP port is shared by all the launchers on the machine, so only the default instance listens on it.
+	if instanceSuffix != "" {
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		ll.Logger.Warning(fmt.Sprintf("Error loading settings: %v\n", err))
+	}
+
+	// Listen on the designated TCP port only if enabled for the game builds which do not read the endpoint from the environment.
+	if !settings.LegacyGamePort {
+		return
+	}
 
-	// Start a TCP listener on the designated port for game client connections
-	listener, err := net.Listen("tcp", "127.0.0.1:"+config.GamePort)
+	listener, err = net.Listen("tcp", "127.0.0.1:"+config.GamePort)
 	if err != nil {
 		ll.Logger.Error(fmt.Sprintf("Error starting listener: %v\n", err))
 		return
 	}
 
+	l.acceptGameClients(listener, false)
+}
+
+// Accept the game client connections until the listener is closed, the clients of a private listener accessible by the user only may be trusted with the session token.
+func (l *Launcher) acceptGameClients(listener net.Listener, private bool) {
 	// Close the listener when the function exits.
 	defer func(listener net.Listener) {
 		err := listener.Close()
@@ -134,42 +324,73 @@ func (l *Launcher) StartGameClientListener() {
 		// Accept incoming connections from the game client.
 		conn, err := listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 			ll.Logger.Error(fmt.Sprintln("Error accepting game client connection:", err))
 			continue
 		}
 
 		// Handle the connection with the game client in a goroutine.
-		go l.handleGameClientConnection(conn)
+		go l.handleGameClientConnection(conn, private)
 	}
 }
 
 // Handle a connection from the game client.
-func (l *Launcher) handleGameClientConnection(conn net.Conn) {
-	// Close the connection and delete it from the connection pool when the goroutine exits.
-	defer func(conn net.Conn) {
-		err := conn.Close()
-		if err != nil {
-			fmt.Println("Error closing game client connection:", err)
+func (l *Launcher) handleGameClientConnection(conn net.Conn, private bool) {
+	session := game.NewSession(conn)
+
+	// Close the connection when the goroutine exits.
+	defer func(session *game.Session) {
+		err := session.Close()
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			ll.Logger.Error(fmt.Sprintf("Error closing game client connection: %v\n", err))
 		}
-	}(conn)
+	}(session)
 
-	reader := bufio.NewReader(conn)
-	message, err := reader.ReadString('\n')
+	// Trust the game client only if it presents the secret passed to a running process of its app.
+	err := session.Handshake(func(appId string, secret string) bool {
+		return private && isGameSecretValid(appId, secret)
+	})
 	if err != nil {
-		fmt.Println("Error reading message from game client:", err)
+		ll.Logger.Error(fmt.Sprintf("Error reading hello from game client: %v\n", err))
 		return
 	}
 
-	var messageStruct struct {
-		AppId string `json:"appId"`
+	client := session.Client()
+	appId := client.AppId
+
+	l.GameClients.add(session)
+
+	ll.Logger.Print(fmt.Sprintf("Game client %s connected for app id: %s, version: %s\n", client.Id, appId, client.Version))
+	l.EmitEvent(events.GameClientConnected, client)
+
+	// Hand the session of the signed-in user to the game, so it does not have to read it from the disk.
+	l.sendSessionToken(session)
+
+	// Send the deep link the game has been launched with.
+	if pending, ok := pendingDeepLinks.LoadAndDelete(appId); ok {
+		err = session.Send(pending.(*deeplink.Link).Message())
+		if err != nil {
+			ll.Logger.Error(fmt.Sprintf("Error sending deep link: %v\n", err))
+		}
 	}
-	err = json.Unmarshal([]byte(message), &messageStruct)
-	if err != nil {
-		fmt.Println("Error parsing message from game client:", err)
-		return
+
+	err = session.Run(func(message game.Message) {
+		switch message.Type {
+		case game.TypeStatus:
+			l.EmitEvent(events.GameClientStatus, session.Client())
+		default:
+			ll.Logger.Warning(fmt.Sprintf("Unexpected %s message from game client %s\n", message.Type, client.Id))
+		}
+	})
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		ll.Logger.Warning(fmt.Sprintf("Game client %s disconnected: %v\n", client.Id, err))
 	}
 
-	appId := messageStruct.AppId
-	gameConnPool[appId] = &conn
-	ll.Logger.Print(fmt.Sprintf("Game client connected for app id: %s\n", appId))
+	// Remove the session before the event, so the connected game clients listed by the frontend no longer include it.
+	l.GameClients.remove(session)
+
+	ll.Logger.Print(fmt.Sprintf("Game client %s disconnected for app id: %s\n", client.Id, appId))
+	l.EmitEvent(events.GameClientDisconnected, session.Client())
 }
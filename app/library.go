@@ -0,0 +1,440 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/model"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ConfigDir is the launcher directory in the user config directory, it stays writable when the launcher is installed into a read-only location.
+var ConfigDir = "LE7EL"
+
+// libraryRegistryFile is the file in the launcher config directory which keeps track of the library folders and the installed apps.
+const libraryRegistryFile = "libraries.json"
+
+var ErrorLibraryNotFound = errors.New("library not found")
+var ErrorLibraryNotEmpty = errors.New("library contains installed apps")
+var ErrorLastLibrary = errors.New("the last library can not be removed")
+var ErrorNotEnoughSpace = errors.New("not enough disk space")
+
+// libraryRegistryMutex guards the library registry file.
+var libraryRegistryMutex sync.Mutex
+
+// libraryRegistry is the persisted list of the library folders and the installation directories of the apps.
+type libraryRegistry struct {
+	Libraries []string          `json:"libraries"` // library root directories
+	Default   string            `json:"default"`   // library used for new installations
+	Apps      map[string]string `json:"apps"`      // app installation directories indexed by the app id
+}
+
+// getConfigDir returns the launcher directory in the user config directory
+func getConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, ConfigDir), nil
+}
+
+// getLegacyLibraryDir returns the apps directory next to the launcher executable used before the library folders were introduced
+func getLegacyLibraryDir() (string, error) {
+	// Get the path to the executable
+	executablePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return filepath.Join(filepath.Dir(executablePath), ApplicationsDir), nil
+}
+
+// loadLibraryRegistry loads the library registry, the apps directory next to the launcher executable and the apps installed there are registered on the first run
+func loadLibraryRegistry() (*libraryRegistry, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &libraryRegistry{Apps: make(map[string]string)}
+	err = utils.ReadJSONFile(filepath.Join(dir, libraryRegistryFile), registry)
+	if err == nil {
+		if registry.Apps == nil {
+			registry.Apps = make(map[string]string)
+		}
+		return registry, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	legacyDir, err := getLegacyLibraryDir()
+	if err != nil {
+		return nil, err
+	}
+
+	registry.Libraries = []string{legacyDir}
+	registry.Default = legacyDir
+
+	entries, err := os.ReadDir(legacyDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read apps directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if id, err := uuid.FromString(entry.Name()); err == nil {
+			registry.Apps[id.String()] = filepath.Join(legacyDir, entry.Name())
+		}
+	}
+
+	return registry, nil
+}
+
+// saveLibraryRegistry saves the library registry
+func saveLibraryRegistry(registry *libraryRegistry) error {
+	dir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSONFile(filepath.Join(dir, libraryRegistryFile), registry, 0644)
+}
+
+// indexOfLibrary returns the index of the library with the given path or -1 if the library is not registered
+func (r *libraryRegistry) indexOfLibrary(path string) int {
+	for i, library := range r.Libraries {
+		if filepath.Clean(library) == filepath.Clean(path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookupAppInstallationDir returns the registered installation directory of the app, the apps directory next to the launcher executable is used for unregistered apps
+func lookupAppInstallationDir(id uuid.UUID) (string, error) {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return "", fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	if dir, ok := registry.Apps[id.String()]; ok {
+		return dir, nil
+	}
+
+	legacyDir, err := getLegacyLibraryDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(legacyDir, id.String()), nil
+}
+
+// getLibraryAppDir returns the installation directory of the app in the given library, the default library is used if the library is empty
+func getLibraryAppDir(id uuid.UUID, library string) (string, error) {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return "", fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	if library == "" {
+		library = registry.Default
+	}
+
+	// Fall back to the apps directory next to the launcher executable if there is no default library, so the app is never installed into the working directory.
+	if library == "" {
+		library, err = getLegacyLibraryDir()
+		if err != nil {
+			return "", err
+		}
+	} else if i := registry.indexOfLibrary(library); i >= 0 {
+		library = registry.Libraries[i]
+	} else {
+		return "", ErrorLibraryNotFound
+	}
+
+	return filepath.Join(library, id.String()), nil
+}
+
+// setAppInstallationDir registers the installation directory of the app
+func setAppInstallationDir(id uuid.UUID, dir string) error {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	registry.Apps[id.String()] = dir
+
+	err = saveLibraryRegistry(registry)
+	if err != nil {
+		return fmt.Errorf("failed to save library registry: %w", err)
+	}
+
+	return nil
+}
+
+// registerAppInstallation registers the app installation directory in the given library, the default library is used if the library is empty
+func registerAppInstallation(id uuid.UUID, library string) (string, error) {
+	dir, err := getLibraryAppDir(id, library)
+	if err != nil {
+		return "", err
+	}
+
+	err = setAppInstallationDir(id, dir)
+	if err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// isAppRegistered returns true if the app installation directory is registered in the library registry, the app is assumed registered if the registry can not be loaded
+func isAppRegistered(id uuid.UUID) bool {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return true
+	}
+
+	_, ok := registry.Apps[id.String()]
+	return ok
+}
+
+// unregisterAppInstallation removes the app installation directory from the library registry
+func unregisterAppInstallation(id uuid.UUID) error {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	delete(registry.Apps, id.String())
+
+	err = saveLibraryRegistry(registry)
+	if err != nil {
+		return fmt.Errorf("failed to save library registry: %w", err)
+	}
+
+	return nil
+}
+
+// addLibrary registers the library folder if it is not registered yet
+func addLibrary(path string) error {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	if registry.indexOfLibrary(path) >= 0 {
+		return nil
+	}
+
+	registry.Libraries = append(registry.Libraries, path)
+	if registry.Default == "" {
+		registry.Default = path
+	}
+
+	err = saveLibraryRegistry(registry)
+	if err != nil {
+		return fmt.Errorf("failed to save library registry: %w", err)
+	}
+
+	return nil
+}
+
+// checkLibraryWritable checks if the files can be created in the library directory
+func checkLibraryWritable(path string) error {
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create library directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(path, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("library directory is not writable: %w", err)
+	}
+	_ = f.Close()
+
+	return os.Remove(f.Name())
+}
+
+// checkLibrarySpace checks if the disk containing the library has enough free space for the given number of bytes
+func checkLibrarySpace(path string, required uint64) error {
+	// The library directory may not exist yet, check the closest existing parent.
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+
+	free, _, err := utils.GetDiskSpace(path)
+	if err != nil {
+		return err
+	}
+
+	if free < required {
+		return fmt.Errorf("%w: %d bytes required, %d bytes available", ErrorNotEnoughSpace, required, free)
+	}
+
+	return nil
+}
+
+// GetLibraries returns the library folders with the free disk space and the installed apps
+func (l *Launcher) GetLibraries() ([]model.Library, error) {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load library registry: %v", err)
+		return nil, fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	libraries := make([]model.Library, 0, len(registry.Libraries))
+	for _, path := range registry.Libraries {
+		library := model.Library{
+			Path:    path,
+			Default: filepath.Clean(path) == filepath.Clean(registry.Default),
+			Apps:    []string{},
+		}
+
+		if _, err = os.Stat(path); err == nil {
+			library.FreeSpace, library.TotalSpace, err = utils.GetDiskSpace(path)
+			if err != nil {
+				runtime.LogWarningf(l.Ctx, "failed to get library disk space: %v", err)
+			}
+		}
+
+		for id, dir := range registry.Apps {
+			if filepath.Clean(filepath.Dir(dir)) == filepath.Clean(path) {
+				library.Apps = append(library.Apps, id)
+			}
+		}
+
+		libraries = append(libraries, library)
+	}
+
+	return libraries, nil
+}
+
+// AddLibrary registers a new library folder, the folder is created if it does not exist
+func (l *Launcher) AddLibrary(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get absolute path: %v", err)
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	err = checkLibraryWritable(path)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to add library: %v", err)
+		return err
+	}
+
+	err = addLibrary(path)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to add library: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveLibrary unregisters the library folder, the library must not contain installed apps and at least one library is kept
+func (l *Launcher) RemoveLibrary(path string) error {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load library registry: %v", err)
+		return fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	i := registry.indexOfLibrary(path)
+	if i < 0 {
+		return ErrorLibraryNotFound
+	}
+
+	for _, dir := range registry.Apps {
+		if filepath.Clean(filepath.Dir(dir)) == filepath.Clean(registry.Libraries[i]) {
+			return ErrorLibraryNotEmpty
+		}
+	}
+
+	if len(registry.Libraries) == 1 {
+		return ErrorLastLibrary
+	}
+
+	removed := registry.Libraries[i]
+	registry.Libraries = append(registry.Libraries[:i], registry.Libraries[i+1:]...)
+	if filepath.Clean(registry.Default) == filepath.Clean(removed) {
+		registry.Default = registry.Libraries[0]
+	}
+
+	err = saveLibraryRegistry(registry)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to save library registry: %v", err)
+		return fmt.Errorf("failed to save library registry: %w", err)
+	}
+
+	return nil
+}
+
+// SetDefaultLibrary sets the library folder used for new installations
+func (l *Launcher) SetDefaultLibrary(path string) error {
+	libraryRegistryMutex.Lock()
+	defer libraryRegistryMutex.Unlock()
+
+	registry, err := loadLibraryRegistry()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load library registry: %v", err)
+		return fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	i := registry.indexOfLibrary(path)
+	if i < 0 {
+		return ErrorLibraryNotFound
+	}
+
+	registry.Default = registry.Libraries[i]
+
+	err = saveLibraryRegistry(registry)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to save library registry: %v", err)
+		return fmt.Errorf("failed to save library registry: %w", err)
+	}
+
+	return nil
+}
+
+// GetAppLocation returns the installation directory of the app with the given id
+func (l *Launcher) GetAppLocation(id uuid.UUID) (string, error) {
+	return l.getAppInstallationDir(id)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/lock"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// lockDir acquires the cross-process lock of the directory, so another launcher copy does not modify it concurrently, the process holding the lock is reported to the frontend
+func (l *Launcher) lockDir(dir string) (*lock.Lock, error) {
+	lk, err := lock.Acquire(dir)
+	if err != nil {
+		var lockedErr *lock.LockedError
+		if errors.As(err, &lockedErr) {
+			runtime.LogErrorf(l.Ctx, "failed to lock directory: %v", err)
+			l.EmitEvent(events.DirectoryLocked, dir, lockedErr.Pid)
+			return nil, err
+		}
+
+		runtime.LogErrorf(l.Ctx, "failed to lock directory %s: %v", dir, err)
+		return nil, fmt.Errorf("failed to lock directory %s: %w", dir, err)
+	}
+
+	return lk, nil
+}
+
+// unlockDir releases the cross-process lock of the directory
+func (l *Launcher) unlockDir(lk *lock.Lock) {
+	err := lk.Release()
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to unlock directory %s: %v", lk.Path, err)
+	}
+}
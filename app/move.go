@@ -0,0 +1,436 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/http"
+	"games.launch.launcher/manifest"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// movesDir is the directory in the launcher config directory keeping the journals of the unfinished app moves.
+const movesDir = "moves"
+
+var ErrorMoveVerificationFailed = errors.New("moved files verification failed")
+
+// moveJournal tracks the progress of an app move, so an interrupted move is resumed instead of started over.
+type moveJournal struct {
+	AppId       string            `json:"appId"`       // the id of the moved app
+	Source      string            `json:"source"`      // the app installation directory before the move
+	Destination string            `json:"destination"` // the app installation directory after the move
+	Copied      map[string]string `json:"copied"`      // hashes of the copied files indexed by the slash separated path relative to the installation directory
+	Verified    bool              `json:"verified"`    // the copy has been verified and registered, only the source removal is pending
+}
+
+// getMoveJournalPath returns the path of the move journal of the app
+func getMoveJournalPath(id string) (string, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, movesDir, id+".json"), nil
+}
+
+// loadMoveJournal loads the move journal of the app, os.ErrNotExist is returned if the app has no unfinished move
+func loadMoveJournal(id string) (*moveJournal, error) {
+	path, err := getMoveJournalPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &moveJournal{}
+	err = utils.ReadJSONFile(path, journal)
+	if err != nil {
+		return nil, err
+	}
+	if journal.Copied == nil {
+		journal.Copied = make(map[string]string)
+	}
+
+	return journal, nil
+}
+
+// saveMoveJournal saves the move journal of the app
+func saveMoveJournal(journal *moveJournal) error {
+	path, err := getMoveJournalPath(journal.AppId)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSONFile(path, journal, 0644)
+}
+
+// removeMoveJournal removes the move journal of the app once the move is finished
+func removeMoveJournal(id string) error {
+	path, err := getMoveJournalPath(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// listMoveFiles returns the slash separated paths and the total size of the files to move, the manifest is skipped as it is written after the copy is verified
+func listMoveFiles(dir string) ([]string, uint64, error) {
+	var files []string
+	var total uint64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == manifest.FileName {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, rel)
+		total += uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return files, total, nil
+}
+
+// copyFile copies the file keeping its permissions and returns the hash of the copied contents
+func copyFile(source string, destination string, w io.Writer) (string, error) {
+	fi, err := os.Stat(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(destination), 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	dst, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+
+	hash, _, err := manifest.HashFile(source, io.MultiWriter(dst, w))
+	if err != nil {
+		_ = dst.Close()
+		return "", err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to close file: %w", err)
+	}
+
+	return hash, nil
+}
+
+// MoveApp moves the installed app into the given library folder, the copied files are verified before the source files are removed
+func (l *Launcher) MoveApp(id uuid.UUID, destinationLibrary string) error {
+	if l.IsUpdatingApp {
+		return ErrorAppIsUpdating
+	}
+
+	if l.Processes.IsRunning(id.String()) {
+		return ErrorAppIsRunning
+	}
+
+	destination, err := getLibraryAppDir(id, destinationLibrary)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get destination dir: %v", err)
+		return err
+	}
+
+	// Resume the unfinished move to the same destination, the partial copy of a move to another destination is discarded.
+	journal, err := loadMoveJournal(id.String())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		runtime.LogErrorf(l.Ctx, "failed to load move journal: %v", err)
+		return fmt.Errorf("failed to load move journal: %w", err)
+	}
+
+	if journal != nil && filepath.Clean(journal.Destination) != filepath.Clean(destination) {
+		if journal.Verified {
+			runtime.LogErrorf(l.Ctx, "previous move of app %s is not finished", id)
+			return fmt.Errorf("previous move of app %s to %s is not finished", id, journal.Destination)
+		}
+
+		runtime.LogWarningf(l.Ctx, "discarding unfinished move of app %s to %s", id, journal.Destination)
+		err = os.RemoveAll(journal.Destination)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to remove partial copy: %v", err)
+			return fmt.Errorf("failed to remove partial copy: %w", err)
+		}
+		journal = nil
+	}
+
+	if journal == nil {
+		source, err := l.getAppInstallationDir(id)
+		if err != nil {
+			return fmt.Errorf("failed to get app installation dir: %w", err)
+		}
+
+		if filepath.Clean(source) == filepath.Clean(destination) {
+			return nil
+		}
+
+		if _, err = os.Stat(source); err != nil {
+			if os.IsNotExist(err) {
+				return ErrorAppNotInstalled
+			}
+			return fmt.Errorf("failed to stat app installation dir: %w", err)
+		}
+
+		if _, err = os.Stat(destination); err == nil {
+			runtime.LogErrorf(l.Ctx, "destination dir already exists: %s", destination)
+			return fmt.Errorf("destination dir already exists: %s", destination)
+		}
+
+		_, total, err := listMoveFiles(source)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to list app files: %v", err)
+			return fmt.Errorf("failed to list app files: %w", err)
+		}
+
+		err = checkLibrarySpace(filepath.Dir(destination), total)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to check library disk space: %v", err)
+			return err
+		}
+
+		journal = &moveJournal{
+			AppId:       id.String(),
+			Source:      source,
+			Destination: destination,
+			Copied:      make(map[string]string),
+		}
+
+		err = saveMoveJournal(journal)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to save move journal: %v", err)
+			return fmt.Errorf("failed to save move journal: %w", err)
+		}
+	}
+
+	return l.moveApp(journal)
+}
+
+// moveApp copies the app files to the destination, verifies the copy, registers the new location and removes the source files, the journal is updated after every step
+func (l *Launcher) moveApp(journal *moveJournal) error {
+	id, err := uuid.FromString(journal.AppId)
+	if err != nil {
+		return fmt.Errorf("failed to parse app id: %w", err)
+	}
+
+	// Both the source and the destination may be used by another launcher copy.
+	for _, dir := range []string{journal.Source, journal.Destination} {
+		lk, err := l.lockDir(dir)
+		if err != nil {
+			l.EmitEvent(events.AppMoveFailed, id.String(), err.Error())
+			return err
+		}
+		defer l.unlockDir(lk)
+	}
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	runtime.LogInfof(l.Ctx, "moving app %s from %s to %s", id, journal.Source, journal.Destination)
+
+	if !journal.Verified {
+		err = l.copyAppFiles(journal)
+		if err != nil {
+			l.EmitEvent(events.AppMoveFailed, id.String(), err.Error())
+			return err
+		}
+
+		err = l.verifyAppFiles(journal)
+		if err != nil {
+			l.EmitEvent(events.AppMoveFailed, id.String(), err.Error())
+			return err
+		}
+
+		err = setAppInstallationDir(id, journal.Destination)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to register app installation: %v", err)
+			l.EmitEvent(events.AppMoveFailed, id.String(), "failed to register app installation")
+			return err
+		}
+
+		journal.Verified = true
+		err = saveMoveJournal(journal)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to save move journal: %v", err)
+		}
+	}
+
+	err = os.RemoveAll(journal.Source)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to remove source dir: %v", err)
+		l.EmitEvent(events.AppMoveFailed, id.String(), "failed to remove source dir")
+		return fmt.Errorf("failed to remove source dir: %w", err)
+	}
+
+	err = removeMoveJournal(journal.AppId)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to remove move journal: %v", err)
+	}
+
+	l.EmitEvent(events.AppMoveCompleted, id.String(), journal.Destination)
+
+	return nil
+}
+
+// copyAppFiles copies the app files which have not been copied yet
+func (l *Launcher) copyAppFiles(journal *moveJournal) error {
+	files, total, err := listMoveFiles(journal.Source)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to list app files: %v", err)
+		return fmt.Errorf("failed to list app files: %w", err)
+	}
+
+	var completed uint64
+	for _, file := range files {
+		source := filepath.Join(journal.Source, filepath.FromSlash(file))
+		destination := filepath.Join(journal.Destination, filepath.FromSlash(file))
+
+		fi, err := os.Stat(source)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to stat file: %v", err)
+			return fmt.Errorf("failed to stat file %s: %w", file, err)
+		}
+
+		// Skip the files copied before the interruption, they are verified with the rest of the files.
+		if _, ok := journal.Copied[file]; ok {
+			if di, err := os.Stat(destination); err == nil && di.Size() == fi.Size() {
+				completed += uint64(fi.Size())
+				l.EmitEvent(events.AppMoveProgress, journal.AppId, completed, total)
+				continue
+			}
+		}
+
+		counter := http.NewDownloadProgressTracker(total, func(progress uint64, _ uint64) {
+			l.EmitEvent(events.AppMoveProgress, journal.AppId, completed+progress, total)
+		})
+		hash, err := copyFile(source, destination, counter)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to copy file: %v", err)
+			return fmt.Errorf("failed to copy file %s: %w", file, err)
+		}
+		completed += uint64(fi.Size())
+
+		journal.Copied[file] = hash
+		err = saveMoveJournal(journal)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to save move journal: %v", err)
+			return fmt.Errorf("failed to save move journal: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// verifyAppFiles checks the hashes of the copied files and writes the integrity manifest to the destination, the mismatching files are removed from the journal to be copied again
+func (l *Launcher) verifyAppFiles(journal *moveJournal) error {
+	m := &manifest.Manifest{AppId: journal.AppId}
+	if v, err := getInstalledVersion(journal.Destination); err == nil && v != nil {
+		m.Version = v.String()
+	}
+
+	var invalid []string
+	for file, hash := range journal.Copied {
+		path := filepath.Join(journal.Destination, filepath.FromSlash(file))
+		copied, size, err := manifest.HashFile(path, nil)
+		if err != nil || !strings.EqualFold(copied, hash) {
+			invalid = append(invalid, file)
+			delete(journal.Copied, file)
+			continue
+		}
+
+		if !manifest.IsIgnored(file) {
+			m.Files = append(m.Files, manifest.File{Path: file, Size: size, Hash: hash})
+		}
+	}
+
+	if len(invalid) > 0 {
+		runtime.LogErrorf(l.Ctx, "moved files verification failed: %s", strings.Join(invalid, ", "))
+		err := saveMoveJournal(journal)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to save move journal: %v", err)
+		}
+		return fmt.Errorf("%w: %s", ErrorMoveVerificationFailed, strings.Join(invalid, ", "))
+	}
+
+	err := manifest.Write(journal.Destination, m)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to write manifest: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// resumeAppMoves resumes the app moves interrupted by the launcher exit
+func (l *Launcher) resumeAppMoves() {
+	dir, err := getConfigDir()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get config dir: %v", err)
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, movesDir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.LogErrorf(l.Ctx, "failed to read move journals: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		journal, err := loadMoveJournal(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to load move journal: %v", err)
+			continue
+		}
+
+		err = l.moveApp(journal)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to resume app move: %v", err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"games.launch.launcher/events"
+	"os"
+	"path/filepath"
+)
+
+// setOffline updates the offline status of the launcher and notifies the frontend when it changes, it is called by the API
+// from any goroutine, so the status is compared, changed and emitted while holding the mutex to keep the events in order
+func (l *Launcher) setOffline(offline bool) {
+	l.offlineMutex.Lock()
+	defer l.offlineMutex.Unlock()
+
+	if l.IsOffline == offline {
+		return
+	}
+
+	l.IsOffline = offline
+	l.EmitEvent(events.LauncherOfflineStatus, offline)
+}
+
+// GetIsOffline returns if the API is unreachable and the launcher uses the cached API responses
+func (l *Launcher) GetIsOffline() bool {
+	l.offlineMutex.Lock()
+	defer l.offlineMutex.Unlock()
+
+	return l.IsOffline
+}
+
+// getCacheDir returns the directory to store the cached API responses, located in the user cache directory, so it stays writable when the launcher is installed into a read-only location
+func getCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache dir: %w", err)
+	}
+
+	return filepath.Join(dir, ConfigDir), nil
+}
+
+// getLegacyCacheDir returns the cache directory next to the launcher executable used by the previous launcher versions
+func getLegacyCacheDir() (string, error) {
+	// Get the path to the executable
+	executablePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return filepath.Join(filepath.Dir(executablePath), ".cache"), nil
+}
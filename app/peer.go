@@ -0,0 +1,78 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"games.launch.launcher/http"
+	"games.launch.launcher/manifest"
+	"games.launch.launcher/peer"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"strings"
+)
+
+// checkFileHash checks the downloaded file against the SHA-256 hash of the release file
+func checkFileHash(path string, hash string) bool {
+	h, _, err := manifest.HashFile(path, nil)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(h, hash)
+}
+
+// downloadReleaseFile downloads the release file trying the peers on the local network first if the peer cache is enabled, the CDN is used if no peer has a file matching the hash
+// Returns true if the downloaded file matches the release file hash and may be shared with the peers.
+func (l *Launcher) downloadReleaseFile(file *sm.File, path string, counter *http.DownloadProgressTracker) (bool, error) {
+	if l.PeerCache == nil || file.Hash == nil || *file.Hash == "" {
+		return false, http.DownloadFile(l.Ctx, path, file.Url, counter)
+	}
+
+	offers, err := l.PeerCache.Find(l.Ctx, *file.Hash, peer.DefaultQueryTimeout)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to query peers: %v", err)
+	}
+
+	for _, offer := range offers {
+		runtime.LogDebugf(l.Ctx, "downloading file %s from peer %s", file.Id, offer.Url)
+		err = http.DownloadFile(l.Ctx, path, offer.Url, counter)
+		if err == nil && checkFileHash(path, *file.Hash) {
+			return true, nil
+		}
+
+		// The peers are not trusted, the file is downloaded again from the next peer or the CDN.
+		runtime.LogWarningf(l.Ctx, "failed to download file %s from peer %s, hash mismatch or error: %v", file.Id, offer.Url, err)
+		if counter != nil {
+			counter.Current = 0
+		}
+	}
+
+	err = http.DownloadFile(l.Ctx, path, file.Url, counter)
+	if err != nil {
+		return false, err
+	}
+
+	return checkFileHash(path, *file.Hash), nil
+}
+
+// keepPeerFile moves the verified downloaded archive into the peer cache, so it is shared with the peers after the extraction
+func (l *Launcher) keepPeerFile(file *sm.File, path string) {
+	if l.PeerCache == nil || file.Hash == nil {
+		return
+	}
+
+	err := l.PeerCache.Keep(*file.Hash, path)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to keep file in peer cache: %v", err)
+	}
+}
+
+// addPeerFile shares the verified installed file with the peers in place
+func (l *Launcher) addPeerFile(file *sm.File, path string) {
+	if l.PeerCache == nil || file.Hash == nil {
+		return
+	}
+
+	err := l.PeerCache.Add(*file.Hash, path)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to add file to peer cache: %v", err)
+	}
+}
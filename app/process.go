@@ -0,0 +1,231 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var ErrorAppIsRunning = errors.New("app is running")
+var ErrorAppNotRunning = errors.New("app is not running")
+
+// appProcess is a launched app process supervised until it exits.
+type appProcess struct {
+	model.RunningApp
+	cmd     *exec.Cmd
+	secret  string // the game secret passed to the process, forgotten when the process exits
+	stopped bool   // the process is being stopped by the launcher, so its exit is not a crash
+}
+
+// AppProcesses keeps track of the app processes launched by the launcher.
+type AppProcesses struct {
+	mu        sync.Mutex
+	processes map[string][]*appProcess // running processes indexed by the app id
+	starting  map[string]int           // number of the launches in progress indexed by the app id
+}
+
+// NewAppProcesses creates a new empty AppProcesses.
+func NewAppProcesses() *AppProcesses {
+	return &AppProcesses{
+		processes: make(map[string][]*appProcess),
+		starting:  make(map[string]int),
+	}
+}
+
+// TryReserve reserves the launch of the app with the given id and returns false if the app is already running or starting
+// unless multiple instances are allowed, the reservation must be released with Release once the process is started or
+// the launch fails.
+func (p *AppProcesses) TryReserve(id string, multiple bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !multiple && (len(p.processes[id]) > 0 || p.starting[id] > 0) {
+		return false
+	}
+
+	p.starting[id]++
+	return true
+}
+
+// Release releases the launch reservation of the app with the given id.
+func (p *AppProcesses) Release(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.starting[id] <= 1 {
+		delete(p.starting, id)
+	} else {
+		p.starting[id]--
+	}
+}
+
+// add starts tracking the process.
+func (p *AppProcesses) add(process *appProcess) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.processes[process.Id] = append(p.processes[process.Id], process)
+}
+
+// remove stops tracking the exited process and returns true if the process has been stopped by the launcher.
+func (p *AppProcesses) remove(process *appProcess) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	processes := p.processes[process.Id][:0]
+	for _, running := range p.processes[process.Id] {
+		if running != process {
+			processes = append(processes, running)
+		}
+	}
+
+	if len(processes) == 0 {
+		delete(p.processes, process.Id)
+	} else {
+		p.processes[process.Id] = processes
+	}
+
+	return process.stopped
+}
+
+// IsRunning returns true if the app with the given id has a running process or is being launched.
+func (p *AppProcesses) IsRunning(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.processes[id]) > 0 || p.starting[id] > 0
+}
+
+// Running returns the running processes of all the apps ordered by the start time.
+func (p *AppProcesses) Running() []model.RunningApp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running := make([]model.RunningApp, 0, len(p.processes))
+	for _, processes := range p.processes {
+		for _, process := range processes {
+			running = append(running, process.RunningApp)
+		}
+	}
+
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].StartedAt.Before(running[j].StartedAt)
+	})
+
+	return running
+}
+
+// stop kills the running processes of the app with the given id and returns the number of the stopped processes.
+func (p *AppProcesses) stop(id string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []string
+	for _, process := range p.processes[id] {
+		process.stopped = true
+		if err := process.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return len(p.processes[id]), fmt.Errorf("failed to kill app process: %v", errs)
+	}
+
+	return len(p.processes[id]), nil
+}
+
+// startAppProcess starts the app process and supervises it until it exits, the lifecycle events are emitted to the frontend
+// The game secret passed to the process is forgotten when the process exits or fails to start.
+func (l *Launcher) startAppProcess(id uuid.UUID, cmd *exec.Cmd, secret string) error {
+	err := cmd.Start()
+	if err != nil {
+		forgetGameSecret(secret)
+		runtime.LogErrorf(l.Ctx, "failed to start app: %v", err)
+		return fmt.Errorf("failed to start app: %w", err)
+	}
+
+	process := &appProcess{
+		RunningApp: model.RunningApp{
+			Id:         id.String(),
+			Pid:        cmd.Process.Pid,
+			Executable: cmd.Path,
+			StartedAt:  time.Now(),
+		},
+		cmd:    cmd,
+		secret: secret,
+	}
+
+	l.Processes.add(process)
+
+	runtime.LogInfof(l.Ctx, "app %s started with pid %d", id, process.Pid)
+	l.EmitEvent(events.AppStarted, process.RunningApp)
+
+	go l.waitAppProcess(process)
+
+	return nil
+}
+
+// waitAppProcess waits for the app process to exit and reports whether it has exited normally or crashed
+func (l *Launcher) waitAppProcess(process *appProcess) {
+	err := process.cmd.Wait()
+
+	stopped := l.Processes.remove(process)
+	forgetGameSecret(process.secret)
+
+	exit := model.AppExit{
+		Id:        process.Id,
+		Pid:       process.Pid,
+		StartedAt: process.StartedAt,
+		ExitedAt:  time.Now(),
+		ExitCode:  -1,
+		Stopped:   stopped,
+	}
+
+	if state := process.cmd.ProcessState; state != nil {
+		exit.ExitCode = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			exit.Signal = status.Signal().String()
+		}
+	}
+
+	if !exit.Stopped && (exit.ExitCode != 0 || exit.Signal != "") {
+		runtime.LogErrorf(l.Ctx, "app %s with pid %d crashed, exit code %d, signal %q: %v", exit.Id, exit.Pid, exit.ExitCode, exit.Signal, err)
+		l.EmitEvent(events.AppCrashed, exit)
+		return
+	}
+
+	runtime.LogInfof(l.Ctx, "app %s with pid %d exited with code %d", exit.Id, exit.Pid, exit.ExitCode)
+	l.EmitEvent(events.AppExited, exit)
+}
+
+// GetRunningApps returns the app processes launched by the launcher which are still running
+func (l *Launcher) GetRunningApps() []model.RunningApp {
+	return l.Processes.Running()
+}
+
+// StopApp kills the running processes of the app, the exit is reported with the app-exited event
+func (l *Launcher) StopApp(id uuid.UUID) error {
+	n, err := l.Processes.stop(id.String())
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to stop app: %v", err)
+		return fmt.Errorf("failed to stop app: %w", err)
+	}
+
+	if n == 0 {
+		return ErrorAppNotRunning
+	}
+
+	runtime.LogInfof(l.Ctx, "stopped %d processes of app %s", n, id)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	goRuntime "runtime"
+	"sort"
+	"strings"
+)
+
+var ErrorProfileNotFound = errors.New("launch profile not found")
+var ErrorInvalidProfile = errors.New("invalid launch profile")
+
+// findLaunchProfile returns the index of the profile with the given name or -1 if the app has no such profile
+func findLaunchProfile(settings model.AppSettings, name string) int {
+	for i, profile := range settings.Profiles {
+		if profile.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isSameVariable returns if the environment variable names are the same, the names are case-insensitive on Windows only
+func isSameVariable(a string, b string) bool {
+	if goRuntime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// isLauncherVariable returns if the environment variable is set by the launcher for the launched app, so a profile can not replace
+// the game client channel endpoint, the launch secret or the SDK path
+func isLauncherVariable(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.HasPrefix(name, GameEnvPrefix) || name == SdkPathEnv
+}
+
+// getProfileEnvironment returns the profile environment variables without the ones set by the launcher, which may be left in the
+// profiles saved by the previous launcher versions
+func (l *Launcher) getProfileEnvironment(profile *model.LaunchProfile) map[string]string {
+	environment := make(map[string]string, len(profile.Environment))
+	for key, value := range profile.Environment {
+		if isLauncherVariable(key) {
+			runtime.LogWarningf(l.Ctx, "ignoring environment variable %s of profile %s set by the launcher", key, profile.Name)
+			continue
+		}
+		environment[key] = value
+	}
+	return environment
+}
+
+// mergeEnvironment returns the environment with the variables replaced by the overrides, the variable names are case-insensitive on Windows
+func mergeEnvironment(env []string, overrides map[string]string) []string {
+	if len(overrides) == 0 {
+		return env
+	}
+
+	merged := make([]string, 0, len(env)+len(overrides))
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+
+		overridden := false
+		for key := range overrides {
+			if isSameVariable(key, name) {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			merged = append(merged, variable)
+		}
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		merged = append(merged, key+"="+overrides[key])
+	}
+
+	return merged
+}
+
+// GetLaunchProfiles returns the launch profiles of the app
+func (l *Launcher) GetLaunchProfiles(id uuid.UUID) ([]model.LaunchProfile, error) {
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Profiles, nil
+}
+
+// SaveLaunchProfile adds the launch profile to the app or replaces the profile with the same name
+func (l *Launcher) SaveLaunchProfile(id uuid.UUID, profile model.LaunchProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("%w: profile name is empty", ErrorInvalidProfile)
+	}
+
+	for key := range profile.Environment {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return fmt.Errorf("%w: invalid environment variable name %q", ErrorInvalidProfile, key)
+		}
+		if isLauncherVariable(key) {
+			return fmt.Errorf("%w: environment variable %s is set by the launcher", ErrorInvalidProfile, key)
+		}
+	}
+
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return err
+	}
+
+	if i := findLaunchProfile(settings, profile.Name); i >= 0 {
+		settings.Profiles[i] = profile
+	} else {
+		settings.Profiles = append(settings.Profiles, profile)
+	}
+
+	return l.SetAppSettings(id, settings)
+}
+
+// DeleteLaunchProfile removes the launch profile from the app, the app is launched without a profile if the default profile is removed
+func (l *Launcher) DeleteLaunchProfile(id uuid.UUID, name string) error {
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return err
+	}
+
+	i := findLaunchProfile(settings, name)
+	if i < 0 {
+		return ErrorProfileNotFound
+	}
+
+	settings.Profiles = append(settings.Profiles[:i], settings.Profiles[i+1:]...)
+	if settings.DefaultProfile == name {
+		settings.DefaultProfile = ""
+	}
+
+	return l.SetAppSettings(id, settings)
+}
+
+// SetDefaultLaunchProfile sets the launch profile used by LaunchApp, the app is launched without a profile if the name is empty
+func (l *Launcher) SetDefaultLaunchProfile(id uuid.UUID, name string) error {
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return err
+	}
+
+	if name != "" && findLaunchProfile(settings, name) < 0 {
+		return ErrorProfileNotFound
+	}
+
+	settings.DefaultProfile = name
+
+	return l.SetAppSettings(id, settings)
+}
+
+// LaunchAppWithProfile launches the app with the arguments, the environment and the working directory of the launch profile
+func (l *Launcher) LaunchAppWithProfile(id uuid.UUID, name string) error {
+	settings, err := l.GetAppSettings(id)
+	if err != nil {
+		return err
+	}
+
+	i := findLaunchProfile(settings, name)
+	if i < 0 {
+		runtime.LogErrorf(l.Ctx, "launch profile %s not found for app %s", name, id)
+		return ErrorProfileNotFound
+	}
+
+	return l.launchApp(id, settings, &settings.Profiles[i], nil)
+}
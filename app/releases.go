@@ -0,0 +1,277 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"fmt"
+	"games.launch.launcher/api"
+	"games.launch.launcher/model"
+	"games.launch.launcher/version"
+	"github.com/Masterminds/semver"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReleasePageSize is the number of releases requested from the backend per release history page.
+const ReleasePageSize int64 = 50
+
+// ReleaseMaxPages is the maximum number of release pages requested per release history, so a backend ignoring the offset can not keep the requests running forever.
+const ReleaseMaxPages = 200
+
+// indexReleases requests all the release pages using the fetch function
+func (l *Launcher) indexReleases(fetch func(offset int64, limit int64) ([]sm.ReleaseV2, error)) ([]sm.ReleaseV2, error) {
+	var releases []sm.ReleaseV2
+	seen := make(map[string]bool)
+	for i := 0; ; i++ {
+		if i == ReleaseMaxPages {
+			runtime.LogWarningf(l.Ctx, "release history has more than %d pages, stopping", ReleaseMaxPages)
+			break
+		}
+
+		page, err := fetch(int64(i)*ReleasePageSize, ReleasePageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		// A page without new releases means the backend has ignored the offset or a stale cached page has been returned.
+		added := 0
+		for _, release := range page {
+			if key := getReleaseKey(release); !seen[key] {
+				seen[key] = true
+				added++
+			}
+		}
+		if len(page) > 0 && added == 0 {
+			runtime.LogWarningf(l.Ctx, "release history page %d repeats the previous releases, stopping", i)
+			break
+		}
+
+		releases = append(releases, page...)
+
+		if int64(len(page)) < ReleasePageSize {
+			break
+		}
+	}
+
+	return releases, nil
+}
+
+// getReleaseKey returns the id of the release or its version if the release has no id
+func getReleaseKey(release sm.ReleaseV2) string {
+	if release.Id != nil {
+		return release.Id.String()
+	}
+
+	return release.Version
+}
+
+// getReleaseHistory converts the releases to the release history sorted from the newest to the oldest release
+func (l *Launcher) getReleaseHistory(releases []sm.ReleaseV2, installed *semver.Version) []model.ReleaseHistoryEntry {
+	sortReleases(releases)
+
+	history := make([]model.ReleaseHistoryEntry, 0, len(releases))
+	for i := len(releases) - 1; i >= 0; i-- {
+		release := releases[i]
+
+		entry := model.ReleaseHistoryEntry{
+			Name:           release.Name,
+			Description:    release.Description,
+			Version:        release.Version,
+			CodeVersion:    release.CodeVersion,
+			ContentVersion: release.ContentVersion,
+		}
+
+		// The releases of the sideloaded or offline exported metadata may have no id.
+		if release.Id != nil {
+			entry.Id = release.Id.String()
+		}
+
+		if installed != nil {
+			if v, err := semver.NewVersion(release.Version); err == nil {
+				entry.Installed = v.Equal(installed)
+			}
+		}
+
+		history = append(history, entry)
+	}
+
+	return history
+}
+
+// sortReleases sorts the releases from the oldest to the newest version, releases with invalid versions go first
+func sortReleases(releases []sm.ReleaseV2) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, errA := semver.NewVersion(releases[i].Version)
+		b, errB := semver.NewVersion(releases[j].Version)
+		if errA != nil || errB != nil {
+			return errA != nil && errB == nil
+		}
+
+		return a.LessThan(b)
+	})
+}
+
+// renderChangelog renders the notes of the releases newer than the installed version up to the target version, the latest release is used if the target version is empty
+func renderChangelog(releases []sm.ReleaseV2, installed *semver.Version, target string) (string, error) {
+	sortReleases(releases)
+
+	var targetVersion *semver.Version
+	if target != "" {
+		v, err := semver.NewVersion(target)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse target version: %w", err)
+		}
+		targetVersion = v
+	}
+
+	var b strings.Builder
+	for i := len(releases) - 1; i >= 0; i-- {
+		release := releases[i]
+
+		v, err := semver.NewVersion(release.Version)
+		if err != nil {
+			continue
+		}
+
+		if installed != nil && !v.GreaterThan(installed) {
+			continue
+		}
+
+		if targetVersion != nil && v.GreaterThan(targetVersion) {
+			continue
+		}
+
+		if release.Name != "" {
+			b.WriteString(fmt.Sprintf("## %s - %s\n\n", v, release.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("## %s\n\n", v))
+		}
+
+		if release.Description != "" {
+			b.WriteString(strings.TrimSpace(release.Description))
+			b.WriteString("\n\n")
+		}
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// getInstalledVersion returns the version installed in the given directory or nil if nothing is installed there
+func getInstalledVersion(dir string) (*semver.Version, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".version")); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check if version file exists: %w", err)
+	}
+
+	return version.ReadVersion(dir)
+}
+
+// getLauncherReleases requests all the launcher releases and the currently installed launcher version
+func (l *Launcher) getLauncherReleases() ([]sm.ReleaseV2, *semver.Version, error) {
+	releases, err := l.indexReleases(func(offset int64, limit int64) ([]sm.ReleaseV2, error) {
+		return api.RequestLauncherReleaseMetadata(l.Ctx, offset, limit)
+	})
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to index launcher releases: %v", err)
+		return nil, nil, fmt.Errorf("failed to index launcher releases: %w", err)
+	}
+
+	// Get the path to the executable
+	executablePath, err := os.Executable()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get executable path: %v", err)
+		return nil, nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	installed, err := getInstalledVersion(filepath.Dir(executablePath))
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get current launcher version: %v", err)
+		return nil, nil, fmt.Errorf("failed to get current launcher version: %w", err)
+	}
+
+	return releases, installed, nil
+}
+
+// getAppReleases returns the releases included into the app metadata and the currently installed app version
+func (l *Launcher) getAppReleases(id uuid.UUID) ([]sm.ReleaseV2, *semver.Version, error) {
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var releases []sm.ReleaseV2
+	if app.Releases != nil {
+		releases = append(releases, app.Releases.Entities...)
+	}
+
+	dir, err := l.getAppInstallationDir(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app installation dir: %v", err)
+		return nil, nil, fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+
+	installed, err := getInstalledVersion(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get current app version: %v", err)
+		return nil, nil, fmt.Errorf("failed to get current app version: %w", err)
+	}
+
+	return releases, installed, nil
+}
+
+// GetLauncherReleaseHistory returns all the launcher releases from the newest to the oldest one
+func (l *Launcher) GetLauncherReleaseHistory() ([]model.ReleaseHistoryEntry, error) {
+	releases, installed, err := l.getLauncherReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.getReleaseHistory(releases, installed), nil
+}
+
+// GetAppReleaseHistory returns all the releases of the app from the newest to the oldest one
+func (l *Launcher) GetAppReleaseHistory(id uuid.UUID) ([]model.ReleaseHistoryEntry, error) {
+	releases, installed, err := l.getAppReleases(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.getReleaseHistory(releases, installed), nil
+}
+
+// GetLauncherChangelog returns the notes of the launcher releases between the installed and the target version, the latest release is used if the target version is empty
+func (l *Launcher) GetLauncherChangelog(targetVersion string) (string, error) {
+	releases, installed, err := l.getLauncherReleases()
+	if err != nil {
+		return "", err
+	}
+
+	changelog, err := renderChangelog(releases, installed, targetVersion)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to render launcher changelog: %v", err)
+		return "", fmt.Errorf("failed to render launcher changelog: %w", err)
+	}
+
+	return changelog, nil
+}
+
+// GetAppChangelog returns the notes of the app releases between the installed and the target version, the latest release is used if the target version is empty
+func (l *Launcher) GetAppChangelog(id uuid.UUID, targetVersion string) (string, error) {
+	releases, installed, err := l.getAppReleases(id)
+	if err != nil {
+		return "", err
+	}
+
+	changelog, err := renderChangelog(releases, installed, targetVersion)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to render app changelog: %v", err)
+		return "", fmt.Errorf("failed to render app changelog: %w", err)
+	}
+
+	return changelog, nil
+}
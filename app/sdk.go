@@ -0,0 +1,509 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"errors"
+	"fmt"
+	"games.launch.launcher/events"
+	"games.launch.launcher/http"
+	"games.launch.launcher/model"
+	"games.launch.launcher/utils"
+	"games.launch.launcher/version"
+	"github.com/Masterminds/semver"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var SdksDir = "sdks"
+
+// SdkPathEnv is the environment variable used to pass the SDK installation directory to the app.
+const SdkPathEnv = "LE7EL_SDK_PATH"
+
+// sdkRegistryFile is the file in the SDK directory which keeps track of the installed SDK releases and the apps using them.
+const sdkRegistryFile = "registry.json"
+
+// sdkTempDir is the directory in the SDK directory the SDK releases are extracted to before they are moved into place.
+const sdkTempDir = ".tmp"
+
+// sdkRegistryMutex guards the SDK registry file and the moves and removals of the SDK installation directories, it is not held while the SDK releases are downloaded.
+var sdkRegistryMutex sync.Mutex
+
+// getSdksDir returns the shared SDK installation directory, located in the user config directory, so it stays writable when the launcher is installed into a read-only location
+func getSdksDir() (string, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, SdksDir), nil
+}
+
+// getLegacySdksDir returns the SDK directory next to the launcher executable used by the previous launcher versions
+func getLegacySdksDir() (string, error) {
+	// Get the path to the executable
+	executablePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return filepath.Join(filepath.Dir(executablePath), SdksDir), nil
+}
+
+// loadSdkRegistry loads the installed SDK releases indexed by the SDK id and version, the registry of the previous launcher versions
+// is loaded on the first run, so the SDK releases installed next to the executable keep being used until they are updated
+func loadSdkRegistry(dir string) (map[string]*model.SdkInstallation, error) {
+	registry := make(map[string]*model.SdkInstallation)
+	err := utils.ReadJSONFile(filepath.Join(dir, sdkRegistryFile), &registry)
+	if err == nil {
+		return registry, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	legacyDir, err := getLegacySdksDir()
+	if err != nil {
+		return registry, nil
+	}
+
+	err = utils.ReadJSONFile(filepath.Join(legacyDir, sdkRegistryFile), &registry)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// saveSdkRegistry saves the installed SDK releases
+func saveSdkRegistry(dir string, registry map[string]*model.SdkInstallation) error {
+	return utils.WriteJSONFile(filepath.Join(dir, sdkRegistryFile), registry, 0644)
+}
+
+// getSdkRegistryKey returns the registry key of the SDK release
+func getSdkRegistryKey(id string, version string) string {
+	return id + "/" + version
+}
+
+// getLatestRelease returns the release with the highest version
+func getLatestRelease(releases []sm.ReleaseV2) (*sm.ReleaseV2, error) {
+	if len(releases) == 0 {
+		return nil, ErrorNoReleases
+	}
+
+	sorted := make([]sm.ReleaseV2, len(releases))
+	copy(sorted, releases)
+	sortReleases(sorted)
+
+	latest := sorted[len(sorted)-1]
+	if _, err := semver.NewVersion(latest.Version); err != nil {
+		return nil, fmt.Errorf("failed to parse release version: %w", err)
+	}
+
+	return &latest, nil
+}
+
+// addSdkReference adds the app to the apps using the SDK release
+func addSdkReference(installation *model.SdkInstallation, appId string) {
+	for _, id := range installation.Apps {
+		if id == appId {
+			return
+		}
+	}
+	installation.Apps = append(installation.Apps, appId)
+}
+
+// removeSdkReference removes the app from the apps using the SDK release
+func removeSdkReference(installation *model.SdkInstallation, appId string) {
+	apps := installation.Apps[:0]
+	for _, id := range installation.Apps {
+		if id != appId {
+			apps = append(apps, id)
+		}
+	}
+	installation.Apps = apps
+}
+
+// releaseSdkReferences removes the app references to the SDK releases except the kept one and removes the SDK releases no longer used by any app
+func (l *Launcher) releaseSdkReferences(registry map[string]*model.SdkInstallation, appId string, keep string) {
+	for key, installation := range registry {
+		if key == keep {
+			continue
+		}
+
+		removeSdkReference(installation, appId)
+		if len(installation.Apps) > 0 {
+			continue
+		}
+
+		runtime.LogInfof(l.Ctx, "removing unused sdk %s", key)
+		if err := os.RemoveAll(installation.Path); err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to remove sdk directory: %v", err)
+			continue
+		}
+		delete(registry, key)
+	}
+}
+
+// installAppSdk installs the SDK release required by the app into the shared SDK directory, if it is not installed yet, and references it from the app
+func (l *Launcher) installAppSdk(app sm.AppV2) error {
+	if app.Sdk == nil || app.Sdk.Releases == nil || len(app.Sdk.Releases.Entities) == 0 {
+		return nil
+	}
+
+	release, err := getLatestRelease(app.Sdk.Releases.Entities)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get latest sdk release: %v", err)
+		return fmt.Errorf("failed to get latest sdk release: %w", err)
+	}
+
+	v, err := semver.NewVersion(release.Version)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to parse sdk release version: %v", err)
+		return fmt.Errorf("failed to parse sdk release version: %w", err)
+	}
+
+	dir, err := getSdksDir()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get sdk dir: %v", err)
+		return fmt.Errorf("failed to get sdk dir: %w", err)
+	}
+
+	sdkId := app.Sdk.Id.String()
+	appId := app.Id.String()
+
+	installed, err := l.referenceSdk(dir, sdkId, v.String(), appId, nil)
+	if err != nil {
+		return err
+	}
+	if installed {
+		runtime.LogInfof(l.Ctx, "sdk %s is already installed", getSdkRegistryKey(sdkId, v.String()))
+		return nil
+	}
+
+	// The release is downloaded without holding the registry mutex, so the apps using the installed SDK releases can be launched meanwhile.
+	tempPath, err := l.installSdkRelease(app, *release, dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempPath); err != nil {
+			runtime.LogWarningf(l.Ctx, "failed to remove temporary sdk directory: %v", err)
+		}
+	}()
+
+	_, err = l.referenceSdk(dir, sdkId, v.String(), appId, func(path string) error {
+		return moveSdkRelease(tempPath, path)
+	})
+	if err != nil {
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to install sdk")
+		return err
+	}
+
+	l.EmitEvent(events.SdkUpdateCompleted, app)
+
+	return nil
+}
+
+// referenceSdk references the SDK release from the app if it is installed and returns if it has been installed, otherwise the SDK
+// release is moved into its installation directory using the install function, if given, and registered while holding the registry mutex
+func (l *Launcher) referenceSdk(dir string, sdkId string, sdkVersion string, appId string, install func(path string) error) (bool, error) {
+	key := getSdkRegistryKey(sdkId, sdkVersion)
+
+	sdkRegistryMutex.Lock()
+	defer sdkRegistryMutex.Unlock()
+
+	registry, err := loadSdkRegistry(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load sdk registry: %v", err)
+		return false, fmt.Errorf("failed to load sdk registry: %w", err)
+	}
+
+	installation, ok := registry[key]
+	if ok {
+		if _, err := os.Stat(filepath.Join(installation.Path, ".version")); err != nil {
+			runtime.LogWarningf(l.Ctx, "sdk %s is registered but not installed, reinstalling", key)
+			ok = false
+		}
+	}
+
+	if !ok {
+		if install == nil {
+			return false, nil
+		}
+
+		installation = &model.SdkInstallation{
+			Id:      sdkId,
+			Version: sdkVersion,
+			Path:    filepath.Join(dir, sdkId, sdkVersion),
+			Apps:    []string{},
+		}
+
+		if err = install(installation.Path); err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to install sdk %s: %v", key, err)
+			return false, fmt.Errorf("failed to install sdk %s: %w", key, err)
+		}
+		registry[key] = installation
+	}
+
+	addSdkReference(installation, appId)
+	l.releaseSdkReferences(registry, appId, key)
+
+	err = saveSdkRegistry(dir, registry)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to save sdk registry: %v", err)
+		return false, fmt.Errorf("failed to save sdk registry: %w", err)
+	}
+
+	return ok, nil
+}
+
+// moveSdkRelease moves the extracted SDK release into its installation directory, replacing the incomplete installation if any
+func moveSdkRelease(tempPath string, path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove incomplete sdk directory: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create sdk directory: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to move sdk directory: %w", err)
+	}
+
+	return nil
+}
+
+// installSdkRelease downloads and extracts the SDK release archives into a new temporary directory in the SDK directory and returns its path,
+// the temporary directory is removed if the installation fails
+func (l *Launcher) installSdkRelease(app sm.AppV2, release sm.ReleaseV2, dir string) (path string, err error) {
+	runtime.LogDebugf(l.Ctx, "installing sdk release: %+v", release)
+
+	archives := getReleaseFiles(release, FileTypeReleaseSdkArchive)
+	if len(archives) == 0 {
+		l.EmitEvent(events.SdkUpdateFailed, app, "no sdk archive file found")
+		return "", fmt.Errorf("no sdk archive file found")
+	}
+
+	downloadDir, err := getDownloadDir()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get download dir: %v", err)
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to get download dir")
+		return "", fmt.Errorf("failed to get download dir: %w", err)
+	}
+
+	lk, err := l.lockDir(downloadDir)
+	if err != nil {
+		l.EmitEvent(events.SdkUpdateFailed, app, "download dir is locked")
+		return "", err
+	}
+	defer l.unlockDir(lk)
+
+	tempDir := filepath.Join(dir, sdkTempDir)
+	if err = os.MkdirAll(tempDir, 0755); err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to create temporary sdk directory: %v", err)
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to create temporary sdk directory")
+		return "", fmt.Errorf("failed to create temporary sdk directory: %w", err)
+	}
+
+	// Every installation uses its own directory, so the concurrent installations of the same release do not overwrite each other.
+	path, err = os.MkdirTemp(tempDir, app.Sdk.Id.String()+"-")
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to create temporary sdk directory: %v", err)
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to create temporary sdk directory")
+		return "", fmt.Errorf("failed to create temporary sdk directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := os.RemoveAll(path); err != nil {
+				runtime.LogWarningf(l.Ctx, "failed to remove temporary sdk directory: %v", err)
+			}
+		}
+	}()
+
+	tempDownloadPath := filepath.Join(downloadDir, "sdk-"+release.Id.String())
+
+	totalSize := getReleaseFilesSize(archives)
+	var completed uint64
+
+	for _, archive := range archives {
+		archivePath := filepath.Join(tempDownloadPath, archive.Id.String())
+
+		counter := http.NewDownloadProgressTracker(totalSize, func(progress uint64, _ uint64) {
+			l.EmitEvent(events.SdkUpdateProgress, app, completed+progress, totalSize)
+		})
+		runtime.LogDebugf(l.Ctx, "downloading sdk archive to %s...", archivePath)
+		shared, err := l.downloadReleaseFile(archive, archivePath, counter)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to download sdk archive: %v", err)
+			l.EmitEvent(events.SdkUpdateFailed, app, "failed to download sdk archive")
+			return "", fmt.Errorf("failed to download sdk archive: %w", err)
+		}
+
+		if archive.Size != nil {
+			completed += uint64(*archive.Size)
+		}
+
+		runtime.LogDebugf(l.Ctx, "extracting sdk archive to %s...", path)
+		err = utils.ExtractArchive(l.Ctx, archivePath, path)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to extract sdk archive: %v", err)
+			l.EmitEvent(events.SdkUpdateFailed, app, "failed to extract sdk archive")
+			return "", fmt.Errorf("failed to extract sdk archive: %w", err)
+		}
+
+		if shared {
+			l.keepPeerFile(archive, archivePath)
+		}
+	}
+
+	v, err := semver.NewVersion(release.Version)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to parse sdk release version: %v", err)
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to parse sdk release version")
+		return "", fmt.Errorf("failed to parse sdk release version: %w", err)
+	}
+
+	err = version.WriteVersion(path, v)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to write sdk version: %v", err)
+		l.EmitEvent(events.SdkUpdateFailed, app, "failed to write sdk version")
+		return "", fmt.Errorf("failed to write sdk version: %w", err)
+	}
+
+	if err := os.RemoveAll(tempDownloadPath); err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to remove temporary download directory: %v", err)
+	}
+
+	return path, nil
+}
+
+// releaseAppSdk removes the app references to the SDK releases and removes the SDK releases no longer used by any app
+func (l *Launcher) releaseAppSdk(id uuid.UUID) error {
+	dir, err := getSdksDir()
+	if err != nil {
+		return fmt.Errorf("failed to get sdk dir: %w", err)
+	}
+
+	sdkRegistryMutex.Lock()
+	defer sdkRegistryMutex.Unlock()
+
+	registry, err := loadSdkRegistry(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load sdk registry: %w", err)
+	}
+
+	l.releaseSdkReferences(registry, id.String(), "")
+
+	return saveSdkRegistry(dir, registry)
+}
+
+// getAppSdk returns the SDK release used by the app or nil if the app does not use an SDK
+func (l *Launcher) getAppSdk(id uuid.UUID) (*model.SdkInstallation, error) {
+	dir, err := getSdksDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sdk dir: %w", err)
+	}
+
+	sdkRegistryMutex.Lock()
+	defer sdkRegistryMutex.Unlock()
+
+	registry, err := loadSdkRegistry(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sdk registry: %w", err)
+	}
+
+	for _, installation := range registry {
+		for _, appId := range installation.Apps {
+			if appId == id.String() {
+				return installation, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// GetInstalledSdks returns the SDK releases installed in the shared SDK directory
+func (l *Launcher) GetInstalledSdks() ([]model.SdkInstallation, error) {
+	dir, err := getSdksDir()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get sdk dir: %v", err)
+		return nil, fmt.Errorf("failed to get sdk dir: %w", err)
+	}
+
+	sdkRegistryMutex.Lock()
+	defer sdkRegistryMutex.Unlock()
+
+	registry, err := loadSdkRegistry(dir)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load sdk registry: %v", err)
+		return nil, fmt.Errorf("failed to load sdk registry: %w", err)
+	}
+
+	installations := make([]model.SdkInstallation, 0, len(registry))
+	for _, installation := range registry {
+		installations = append(installations, *installation)
+	}
+
+	return installations, nil
+}
+
+// CheckForSdkUpdates checks if a newer release of the SDK used by the app is available
+func (l *Launcher) CheckForSdkUpdates(id uuid.UUID) (UpdateAvailability, error) {
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
+		return UpdateAvailabilityUnknown, err
+	}
+
+	if app.Sdk == nil || app.Sdk.Releases == nil || len(app.Sdk.Releases.Entities) == 0 {
+		return UpdateAvailabilityUpToDate, nil
+	}
+
+	release, err := getLatestRelease(app.Sdk.Releases.Entities)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get latest sdk release: %v", err)
+		return UpdateAvailabilityUnknown, err
+	}
+
+	installation, err := l.getAppSdk(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app sdk: %v", err)
+		return UpdateAvailabilityUnknown, err
+	}
+
+	availability := UpdateAvailabilityAvailable
+	if installation != nil && installation.Id == app.Sdk.Id.String() {
+		installed, err := semver.NewVersion(installation.Version)
+		latest, err1 := semver.NewVersion(release.Version)
+		if err == nil && err1 == nil && !latest.GreaterThan(installed) {
+			availability = UpdateAvailabilityUpToDate
+		}
+	}
+
+	l.EmitEvent(events.SdkUpdateAvailable, id, availability)
+
+	return availability, nil
+}
+
+// UpdateAppSdk installs the latest release of the SDK used by the app without updating the app itself
+func (l *Launcher) UpdateAppSdk(id uuid.UUID) error {
+	if l.IsUpdatingApp {
+		return ErrorAppIsUpdating
+	}
+
+	app, err := l.GetAppMetadata(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get app metadata: %v", err)
+		return err
+	}
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	return l.installAppSdk(*app)
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/config"
+	"games.launch.launcher/model"
+	"games.launch.launcher/peer"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// settingsFile is the file in the launcher config directory which keeps the launcher settings.
+const settingsFile = "settings.json"
+
+// DefaultPeerCacheMaxSize is the default size limit of the release archives kept for the peers.
+const DefaultPeerCacheMaxSize = 10 << 30
+
+// settingsMutex guards the settings file.
+var settingsMutex sync.Mutex
+
+// getDefaultSettings returns the settings used until the user changes them, the peer cache is opt-in
+func getDefaultSettings() model.Settings {
+	port, _ := strconv.Atoi(config.PeerPort)
+	return model.Settings{
+		PeerCache: model.PeerCacheSettings{
+			Enabled:       false,
+			DiscoveryPort: port,
+			MaxSize:       DefaultPeerCacheMaxSize,
+		},
+	}
+}
+
+// loadSettings loads the launcher settings, the default settings are returned if the settings have not been saved yet
+func loadSettings() (model.Settings, error) {
+	settings := getDefaultSettings()
+
+	dir, err := getConfigDir()
+	if err != nil {
+		return settings, err
+	}
+
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	err = utils.ReadJSONFile(filepath.Join(dir, settingsFile), &settings)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return getDefaultSettings(), err
+	}
+
+	return settings, nil
+}
+
+// saveSettings saves the launcher settings
+func saveSettings(settings model.Settings) error {
+	dir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	return utils.WriteJSONFile(filepath.Join(dir, settingsFile), settings, 0644)
+}
+
+// GetSettings returns the launcher settings
+func (l *Launcher) GetSettings() (model.Settings, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load settings: %v", err)
+		return settings, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SetSettings saves the launcher settings and restarts the peer cache with the new settings
+func (l *Launcher) SetSettings(settings model.Settings) error {
+	if settings.PeerCache.DiscoveryPort == 0 {
+		settings.PeerCache.DiscoveryPort = getDefaultSettings().PeerCache.DiscoveryPort
+	}
+
+	err := saveSettings(settings)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to save settings: %v", err)
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	l.stopPeerCache()
+
+	return l.startPeerCache(settings.PeerCache)
+}
+
+// getAppSettings returns the launcher settings of the app
+func getAppSettings(id uuid.UUID) (model.AppSettings, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return model.AppSettings{}, err
+	}
+
+	return settings.Apps[id.String()], nil
+}
+
+// GetAppSettings returns the launcher settings of the app
+func (l *Launcher) GetAppSettings(id uuid.UUID) (model.AppSettings, error) {
+	settings, err := getAppSettings(id)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load settings: %v", err)
+		return settings, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SetAppSettings saves the launcher settings of the app
+func (l *Launcher) SetAppSettings(id uuid.UUID, appSettings model.AppSettings) error {
+	settings, err := loadSettings()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load settings: %v", err)
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.Apps == nil {
+		settings.Apps = make(map[string]model.AppSettings)
+	}
+	settings.Apps[id.String()] = appSettings
+
+	err = saveSettings(settings)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to save settings: %v", err)
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return nil
+}
+
+// getPeerCacheDir returns the directory keeping the release archives shared with the peers, it is kept in the config directory, so separate launcher instances have their own caches
+func getPeerCacheDir() (string, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "peer"), nil
+}
+
+// startPeerCache starts sharing the downloaded files with the peers if the peer cache is enabled
+func (l *Launcher) startPeerCache(settings model.PeerCacheSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	dir, err := getPeerCacheDir()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get peer cache dir: %v", err)
+		return fmt.Errorf("failed to get peer cache dir: %w", err)
+	}
+
+	c := peer.New(dir, settings)
+	err = c.Start()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to start peer cache: %v", err)
+		return fmt.Errorf("failed to start peer cache: %w", err)
+	}
+
+	l.PeerCache = c
+
+	return nil
+}
+
+// stopPeerCache stops sharing the files with the peers
+func (l *Launcher) stopPeerCache() {
+	if l.PeerCache == nil {
+		return
+	}
+
+	err := l.PeerCache.Stop()
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to stop peer cache: %v", err)
+	}
+
+	l.PeerCache = nil
+}
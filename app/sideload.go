@@ -0,0 +1,244 @@
+package app
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"games.launch.launcher/api"
+	"games.launch.launcher/events"
+	"games.launch.launcher/manifest"
+	"games.launch.launcher/utils"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrorInvalidPackage = errors.New("invalid app package")
+
+// loadPackageMetadata loads the app metadata of a local release package from the metadata file or from the metadata embedded into the exported app package if the metadata path is empty
+// The metadata has the AppV2 format with the release being installed in the releases, the latest release is used if there are several.
+func loadPackageMetadata(archivePath string, metadataPath string) (*sm.AppV2, *sm.ReleaseV2, error) {
+	app := &sm.AppV2{}
+	if metadataPath != "" {
+		err := utils.ReadJSONFile(metadataPath, app)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read package metadata: %w", err)
+		}
+	} else {
+		b, err := utils.ReadArchiveFile(archivePath, PackageMetadataFile)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil, fmt.Errorf("%w: package has no metadata", ErrorInvalidPackage)
+			}
+			return nil, nil, fmt.Errorf("failed to read package metadata: %w", err)
+		}
+
+		err = json.Unmarshal(b, app)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode package metadata: %w", err)
+		}
+	}
+
+	if app.Id == nil || app.Id.IsNil() {
+		return nil, nil, fmt.Errorf("%w: metadata has no app id", ErrorInvalidPackage)
+	}
+
+	if app.Releases == nil || len(app.Releases.Entities) == 0 {
+		return nil, nil, fmt.Errorf("%w: metadata has no release", ErrorInvalidPackage)
+	}
+
+	release, err := getLatestRelease(app.Releases.Entities)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrorInvalidPackage, err)
+	}
+
+	return app, release, nil
+}
+
+// verifyPackageArchive checks the archive against the size and the SHA-256 hash of the release archive files listed in the metadata, the check is skipped if the metadata has neither
+func verifyPackageArchive(archivePath string, release sm.ReleaseV2) error {
+	files := getReleaseFiles(release, FileTypeReleaseArchive)
+
+	var hashes []string
+	for _, file := range files {
+		if file.Hash != nil && *file.Hash != "" {
+			hashes = append(hashes, *file.Hash)
+		}
+	}
+
+	if len(hashes) == 0 {
+		if len(files) == 1 && files[0].Size != nil {
+			fi, err := os.Stat(archivePath)
+			if err != nil {
+				return fmt.Errorf("failed to stat archive: %w", err)
+			}
+			if fi.Size() != *files[0].Size {
+				return fmt.Errorf("%w: archive size %d does not match release size %d", ErrorInvalidPackage, fi.Size(), *files[0].Size)
+			}
+		}
+		return nil
+	}
+
+	hash, _, err := manifest.HashFile(archivePath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to hash archive: %w", err)
+	}
+
+	for _, h := range hashes {
+		if strings.EqualFold(h, hash) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: archive hash does not match the release", ErrorInvalidPackage)
+}
+
+// SideloadApp installs the app from a local release archive described by the metadata JSON file without downloading, the default library is used if the library is empty
+func (l *Launcher) SideloadApp(archivePath string, metadataPath string, library string) error {
+	app, release, err := loadPackageMetadata(archivePath, metadataPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to load package metadata: %v", err)
+		return err
+	}
+
+	return l.installAppPackage(*app, *release, archivePath, library, metadataPath == "")
+}
+
+// ImportApp installs the app from the package exported by ExportApp without downloading, the default library is used if the library is empty
+func (l *Launcher) ImportApp(packagePath string, library string) error {
+	return l.SideloadApp(packagePath, "", library)
+}
+
+// installAppPackage extracts the local release archive into the app installation directory, verifies the extracted files and writes the release version
+// The exported app packages are not release archives, so they are verified with the embedded integrity manifest only.
+func (l *Launcher) installAppPackage(app sm.AppV2, release sm.ReleaseV2, archivePath string, library string, exported bool) (err error) {
+	id := *app.Id
+
+	if l.IsUpdatingApp {
+		return ErrorAppIsUpdating
+	}
+
+	// The running app files can not be replaced.
+	if l.Processes.IsRunning(id.String()) {
+		runtime.LogErrorf(l.Ctx, "app %s is running", id)
+		return ErrorAppIsRunning
+	}
+
+	l.IsUpdatingApp = true
+	defer func() {
+		l.IsUpdatingApp = false
+	}()
+
+	runtime.LogInfof(l.Ctx, "installing app %s release %s from %s", id, release.Version, archivePath)
+
+	fi, err := os.Stat(archivePath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to stat package archive: %v", err)
+		return fmt.Errorf("failed to stat package archive: %w", err)
+	}
+
+	if !exported {
+		err = verifyPackageArchive(archivePath, release)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to verify package archive: %v", err)
+			return err
+		}
+	}
+
+	// Update the installed app in place, otherwise install into the library.
+	registered := false
+	appInstallationPath, err := l.getAppInstallationDir(id)
+	if err != nil {
+		return fmt.Errorf("failed to get app installation dir: %w", err)
+	}
+	if _, err = os.Stat(appInstallationPath); err != nil {
+		appInstallationPath, err = registerAppInstallation(id, library)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to register app installation: %v", err)
+			return fmt.Errorf("failed to register app installation: %w", err)
+		}
+		registered = true
+	}
+
+	lk, err := l.lockDir(appInstallationPath)
+	if err != nil {
+		return err
+	}
+	defer l.unlockDir(lk)
+
+	// Forget the new app location if the installation fails.
+	if registered {
+		defer func() {
+			l.forgetFailedAppInstallation(id, err)
+		}()
+	}
+
+	err = checkLibrarySpace(filepath.Dir(appInstallationPath), uint64(fi.Size()))
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to check library disk space: %v", err)
+		return err
+	}
+
+	// The manifest of the previously installed release does not match the package files.
+	err = removeAppManifest(appInstallationPath)
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "%v", err)
+		return err
+	}
+
+	// The metadata embedded into the exported package is not a release file.
+	var skip []string
+	if exported {
+		skip = append(skip, PackageMetadataFile)
+	}
+
+	err = l.extractAppArchive(app, archivePath, appInstallationPath, skip...)
+	if err != nil {
+		return err
+	}
+
+	// Verify the extracted files if the package contains the integrity manifest.
+	m, err := manifest.Read(appInstallationPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		runtime.LogWarningf(l.Ctx, "failed to read manifest: %v", err)
+	}
+	if m == nil && exported {
+		runtime.LogErrorf(l.Ctx, "exported package has no manifest")
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "package has no manifest")
+		return fmt.Errorf("%w: package has no manifest", ErrorInvalidPackage)
+	}
+	if m != nil {
+		invalid, err := manifest.Verify(appInstallationPath, m, func(progress uint64, total uint64) {
+			l.EmitEvent(events.AppVerifyProgress, id.String(), progress, total)
+		})
+		if err == nil && len(invalid) > 0 {
+			err = fmt.Errorf("%w: %s", ErrorInstallationVerificationFailed, strings.Join(invalid, ", "))
+		}
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to verify installed files: %v", err)
+			l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to verify installed files")
+			return err
+		}
+	}
+
+	err = l.writeAppReleaseVersion(appInstallationPath, release)
+	if err != nil {
+		l.SetAppUpdateStatus(false, events.AppUpdateFailed, app, "failed to write version")
+		return err
+	}
+
+	// Cache the package metadata, so the sideloaded app is shown while offline.
+	err = api.StoreAppMetadata(l.Ctx, id, &app)
+	if err != nil {
+		runtime.LogWarningf(l.Ctx, "failed to cache app metadata: %v", err)
+	}
+
+	l.SetAppUpdateStatus(false, events.AppUpdateCompleted, app)
+
+	// Continue the deep link action which has offered the installation.
+	l.resumeDeepLink(id)
+
+	return nil
+}
@@ -0,0 +1,348 @@
+package app
+
+import (
+	"fmt"
+	"games.launch.launcher/api"
+	"games.launch.launcher/lock"
+	"games.launch.launcher/model"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// responseCacheDir is the directory in the cache directory keeping the API responses.
+const responseCacheDir = "responses"
+
+// updaterLogFile is the log file written next to the launcher executable by the launcher updater.
+const updaterLogFile = "updater.log"
+
+// getMovingDirs returns the source and destination directories of the unfinished app moves, they are resumed on the next start and must not be removed
+func getMovingDirs() (map[string]bool, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]bool)
+
+	entries, err := os.ReadDir(filepath.Join(dir, movesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dirs, nil
+		}
+		return nil, fmt.Errorf("failed to read move journals: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		journal, err := loadMoveJournal(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load move journal: %w", err)
+		}
+
+		dirs[filepath.Clean(journal.Source)] = true
+		dirs[filepath.Clean(journal.Destination)] = true
+	}
+
+	return dirs, nil
+}
+
+// getStorageItems returns the entries of the directory as storage items with their sizes
+func getStorageItems(dir string) ([]model.StorageItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items := make([]model.StorageItem, 0, len(entries))
+	for _, entry := range entries {
+		// The lock files are removed by their owners.
+		if entry.Name() == lock.Dir {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		size, err := utils.GetSize(path)
+		if err != nil {
+			return nil, err
+		}
+
+		item := model.StorageItem{Path: path, Size: size}
+		// The app downloads are kept in the directories named by the app id, optionally followed by a suffix.
+		if id, err := uuid.FromString(strings.TrimSuffix(entry.Name(), "-components")); err == nil {
+			item.AppId = id.String()
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// getStorageReport collects the disk space used by the installed apps, the caches and the leftovers
+func (l *Launcher) getStorageReport() (*model.StorageReport, error) {
+	libraryRegistryMutex.Lock()
+	registry, err := loadLibraryRegistry()
+	libraryRegistryMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load library registry: %w", err)
+	}
+
+	moving, err := getMovingDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	executablePath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	launcherDir := filepath.Dir(executablePath)
+
+	report := &model.StorageReport{}
+
+	legacyDir, err := getLegacyLibraryDir()
+	if err != nil {
+		return nil, err
+	}
+
+	registered := make(map[string]bool)
+	for _, dir := range registry.Apps {
+		registered[filepath.Clean(dir)] = true
+	}
+
+	// The app directories left in the library without being registered, e.g. by a failed uninstall. The unregistered apps
+	// of the legacy library are still resolved to their directory, so they are installed apps rather than orphans.
+	isOrphan := func(id string, dir string) bool {
+		dir = filepath.Clean(dir)
+		if registered[dir] || moving[dir] {
+			return false
+		}
+		if _, ok := registry.Apps[id]; !ok && dir == filepath.Join(legacyDir, id) {
+			return false
+		}
+		return true
+	}
+
+	for id, dir := range registry.Apps {
+		if _, err = os.Stat(dir); err != nil {
+			continue
+		}
+
+		size, err := utils.GetSize(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app size: %w", err)
+		}
+
+		app := model.AppStorage{Id: id, Path: dir, Size: size}
+		if metadata, ok := l.Catalog.Get(id); ok {
+			app.Name = metadata.Name
+		}
+		report.Apps = append(report.Apps, app)
+	}
+
+	sort.Slice(report.Apps, func(i, j int) bool {
+		return report.Apps[i].Path < report.Apps[j].Path
+	})
+
+	for _, library := range registry.Libraries {
+		items, err := getStorageItems(library)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read library: %w", err)
+		}
+		for _, item := range items {
+			if item.AppId != "" && filepath.Base(item.Path) == item.AppId && isOrphan(item.AppId, item.Path) {
+				report.Orphans = append(report.Orphans, item)
+			}
+		}
+
+		// The downloads interrupted by the launcher exit or failed installs.
+		items, err = getStorageItems(filepath.Join(library, ".tmp"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read library temp dir: %w", err)
+		}
+		report.PartialDownloads = append(report.PartialDownloads, items...)
+	}
+
+	// The launcher update and SDK downloads.
+	items, err := getStorageItems(filepath.Join(launcherDir, ".tmp"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temp dir: %w", err)
+	}
+	report.PartialDownloads = append(report.PartialDownloads, items...)
+
+	for _, item := range report.PartialDownloads {
+		report.TempSize += item.Size
+	}
+
+	if !l.IsUpdatingLauncher {
+		logPath := filepath.Join(launcherDir, updaterLogFile)
+		if fi, err := os.Stat(logPath); err == nil {
+			report.Logs = append(report.Logs, model.StorageItem{Path: logPath, Size: uint64(fi.Size())})
+		}
+	}
+
+	if dir, err := getCacheDir(); err == nil {
+		report.CacheSize, err = utils.GetSize(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache size: %w", err)
+		}
+	}
+
+	if dir, err := getPeerCacheDir(); err == nil {
+		report.PeerCacheSize, err = utils.GetSize(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get peer cache size: %w", err)
+		}
+	}
+
+	if dir, err := getSdksDir(); err == nil {
+		report.SdkSize, err = utils.GetSize(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sdk size: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// GetStorageReport returns the disk space used by the installed apps, the caches and the temporary data, together with the orphaned app directories, the partial downloads and the stale logs which may be cleaned up
+func (l *Launcher) GetStorageReport() (*model.StorageReport, error) {
+	report, err := l.getStorageReport()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get storage report: %v", err)
+		return nil, fmt.Errorf("failed to get storage report: %w", err)
+	}
+
+	return report, nil
+}
+
+// CleanupStorage removes the leftovers selected by the options to reclaim disk space, nothing is removed while an app or the launcher is updating
+func (l *Launcher) CleanupStorage(options model.CleanupOptions) (*model.CleanupResult, error) {
+	if l.IsUpdatingLauncher {
+		return nil, ErrorLauncherIsUpdating
+	}
+
+	if l.IsUpdatingApp {
+		return nil, ErrorAppIsUpdating
+	}
+
+	// Block the app installs, so the temporary data is not removed while being downloaded.
+	if !options.DryRun {
+		l.IsUpdatingApp = true
+		defer func() {
+			l.IsUpdatingApp = false
+		}()
+	}
+
+	report, err := l.getStorageReport()
+	if err != nil {
+		runtime.LogErrorf(l.Ctx, "failed to get storage report: %v", err)
+		return nil, fmt.Errorf("failed to get storage report: %w", err)
+	}
+
+	result := &model.CleanupResult{}
+
+	// The items are removed only while holding the locks of the directories, so the data used by another launcher copy is kept.
+	remove := func(item model.StorageItem, lockDirs ...string) bool {
+		if !options.DryRun {
+			for _, dir := range lockDirs {
+				lk, err := lock.Acquire(dir)
+				if err != nil {
+					runtime.LogWarningf(l.Ctx, "skipping %s: %v", item.Path, err)
+					result.Failed = append(result.Failed, item)
+					return false
+				}
+				defer l.unlockDir(lk)
+			}
+
+			runtime.LogInfof(l.Ctx, "removing %s", item.Path)
+			if err := os.RemoveAll(item.Path); err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to remove %s: %v", item.Path, err)
+				result.Failed = append(result.Failed, item)
+				return false
+			}
+		}
+
+		result.Removed = append(result.Removed, item)
+		result.Reclaimed += item.Size
+		return true
+	}
+
+	if options.PartialDownloads {
+		for _, item := range report.PartialDownloads {
+			lockDirs := []string{filepath.Dir(item.Path)}
+			if item.AppId != "" {
+				// The app downloads lock their own temp dir and the app installation dir in the library containing the temp dir.
+				lockDirs = append(lockDirs, item.Path, filepath.Join(filepath.Dir(filepath.Dir(item.Path)), item.AppId))
+			}
+			remove(item, lockDirs...)
+		}
+	}
+
+	if options.Logs {
+		for _, item := range report.Logs {
+			remove(item)
+		}
+	}
+
+	// Only the orphans confirmed by the user are removed, the directories which are no longer orphaned are kept.
+	confirmed := make(map[string]bool, len(options.Orphans))
+	for _, path := range options.Orphans {
+		confirmed[filepath.Clean(path)] = true
+	}
+
+	for _, item := range report.Orphans {
+		if !confirmed[filepath.Clean(item.Path)] {
+			continue
+		}
+
+		if !remove(item, item.Path) || options.DryRun {
+			continue
+		}
+
+		// Remove the SDK used by the app if the app is not installed elsewhere and no other app uses it.
+		id := uuid.FromStringOrNil(item.AppId)
+		if isAppRegistered(id) {
+			continue
+		}
+
+		err = l.releaseAppSdk(id)
+		if err != nil {
+			runtime.LogErrorf(l.Ctx, "failed to release app sdk: %v", err)
+		}
+	}
+
+	if options.Cache {
+		if dir, err := getCacheDir(); err == nil {
+			item := model.StorageItem{Path: filepath.Join(dir, responseCacheDir)}
+			item.Size, _ = utils.GetSize(item.Path)
+			if options.DryRun {
+				result.Removed = append(result.Removed, item)
+				result.Reclaimed += item.Size
+			} else if err = api.ClearCache(); err != nil {
+				runtime.LogErrorf(l.Ctx, "failed to clear response cache: %v", err)
+				result.Failed = append(result.Failed, item)
+			} else {
+				result.Removed = append(result.Removed, item)
+				result.Reclaimed += item.Size
+			}
+		}
+	}
+
+	runtime.LogInfof(l.Ctx, "storage cleanup reclaimed %d bytes", result.Reclaimed)
+
+	return result, nil
+}
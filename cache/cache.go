@@ -0,0 +1,82 @@
+// Package cache provides a persistent on-disk store for the API responses, so they survive the launcher restart and are served when the API is unreachable.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	ll "games.launch.launcher/logger"
+	"games.launch.launcher/utils"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a single cached API response.
+type Entry struct {
+	Key       string          `json:"key"`       // the cache key
+	UpdatedAt time.Time       `json:"updatedAt"` // the time the response was received
+	Payload   json.RawMessage `json:"payload"`   // the response payload
+}
+
+// Store persists cache entries as JSON files in the store directory, one file per key.
+type Store struct {
+	Dir string
+
+	mu sync.RWMutex
+}
+
+// NewStore creates a new Store located in the given directory.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// path returns the file path of the entry with the given key.
+func (s *Store) path(key string) string {
+	return filepath.Join(s.Dir, key+".json")
+}
+
+// Put stores the given value under the given key.
+func (s *Store) Put(key string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to encode cache entry %s: %v\n", key, err))
+		return fmt.Errorf("failed to encode cache entry %s: %w", key, err)
+	}
+
+	entry := Entry{
+		Key:       key,
+		UpdatedAt: time.Now(),
+		Payload:   payload,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return utils.WriteJSONFile(s.path(key), entry, 0644)
+}
+
+// Get loads the entry with the given key and decodes its payload into the given value, returns os.ErrNotExist if there is no such entry.
+func (s *Store) Get(key string, v any) (*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entry Entry
+	err := utils.ReadJSONFile(s.path(key), &entry)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("failed to read cache entry %s: %w", key, err)
+	}
+
+	if v != nil {
+		if err = json.Unmarshal(entry.Payload, v); err != nil {
+			ll.Logger.Error(fmt.Sprintf("failed to decode cache entry %s: %v\n", key, err))
+			return nil, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+		}
+	}
+
+	return &entry, nil
+}
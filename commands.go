@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"games.launch.launcher/app"
+	"games.launch.launcher/ipc"
+	ll "games.launch.launcher/logger"
+	"github.com/gofrs/uuid"
+	"github.com/wailsapp/wails/v2"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"net"
+	"os"
+	"time"
+)
+
+// remoteCommandTimeout is the time the first launcher instance has to execute the command.
+const remoteCommandTimeout = 30 * time.Second
+
+// command parses the command line arguments and returns the function executing the command.
+type command func(args []string) (func(l *app.Launcher) error, error)
+
+// commands are the command line commands executed by the launcher without the UI, indexed by the command name passed as the first argument.
+var commands = map[string]command{
+	"sideload": parseSideloadCommand,
+	"export":   parseExportCommand,
+}
+
+// remoteCommand parses the command line arguments of the command sent to the running launcher and returns the command arguments.
+type remoteCommand func(args []string) ([]string, error)
+
+// remoteCommands are the command line commands sent to the running launcher instance, indexed by the command name passed as the first argument.
+var remoteCommands = map[string]remoteCommand{
+	ipc.CommandPing:    parseNoArgumentsCommand(ipc.CommandPing),
+	ipc.CommandShow:    parseNoArgumentsCommand(ipc.CommandShow),
+	ipc.CommandRunning: parseNoArgumentsCommand(ipc.CommandRunning),
+	ipc.CommandLaunch:  parseAppCommand(ipc.CommandLaunch),
+	ipc.CommandStop:    parseAppCommand(ipc.CommandStop),
+}
+
+// parseSideloadCommand parses the arguments of the sideload command installing an app from a local release package.
+func parseSideloadCommand(args []string) (func(l *app.Launcher) error, error) {
+	fs := flag.NewFlagSet("sideload", flag.ContinueOnError)
+	archive := fs.String("archive", "", "path to the release archive (zip, tar or tar.gz)")
+	metadata := fs.String("metadata", "", "path to the app metadata JSON file, may be omitted for the packages exported by the launcher")
+	library := fs.String("library", "", "library folder to install the app into, the default library is used if empty")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *archive == "" {
+		fs.Usage()
+		return nil, errors.New("archive is required")
+	}
+
+	return func(l *app.Launcher) error {
+		return l.SideloadApp(*archive, *metadata, *library)
+	}, nil
+}
+
+// parseExportCommand parses the arguments of the export command writing an installed app into a portable package.
+func parseExportCommand(args []string) (func(l *app.Launcher) error, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	id := fs.String("id", "", "id of the installed app")
+	output := fs.String("output", "", "path of the package file to write")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	appId, err := uuid.FromString(*id)
+	if err != nil || *output == "" {
+		fs.Usage()
+		return nil, errors.New("valid app id and output are required")
+	}
+
+	return func(l *app.Launcher) error {
+		return l.ExportApp(appId, *output)
+	}, nil
+}
+
+// parseNoArgumentsCommand returns the parser of the remote command without arguments.
+func parseNoArgumentsCommand(name string) remoteCommand {
+	return func(args []string) ([]string, error) {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// parseAppCommand returns the parser of the remote command executed for the app with the given id.
+func parseAppCommand(name string) remoteCommand {
+	return func(args []string) ([]string, error) {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		id := fs.String("id", "", "id of the app")
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		appId, err := uuid.FromString(*id)
+		if err != nil {
+			fs.Usage()
+			return nil, errors.New("valid app id is required")
+		}
+
+		return []string{appId.String()}, nil
+	}
+}
+
+// sendCommand sends the command to the running launcher instance, prints the response and exits with a non-zero code if the command fails.
+func sendCommand(conn net.Conn, name string, args []string) {
+	token, err := app.ReadInstanceToken()
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error reading instance token: %v\n", err))
+		os.Exit(1)
+	}
+
+	err = conn.SetDeadline(time.Now().Add(remoteCommandTimeout))
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error setting connection deadline: %v\n", err))
+	}
+
+	response, err := ipc.Call(conn, token, name, args...)
+
+	if closeErr := conn.Close(); closeErr != nil {
+		ll.Logger.Error(fmt.Sprintf("Error closing connection: %v\n", closeErr))
+	}
+
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error sending %s command: %v\n", name, err))
+		os.Exit(1)
+	}
+
+	if !response.Ok {
+		ll.Logger.Error(fmt.Sprintf("Command %s failed: %s\n", name, response.Message))
+		_, _ = fmt.Fprintln(os.Stderr, response.Message)
+		os.Exit(1)
+	}
+
+	ll.Logger.Info(fmt.Sprintf("Command %s completed: %s\n", name, response.Message))
+	_, _ = fmt.Println(response.Message)
+	os.Exit(0)
+}
+
+// runCommand runs the command line command without showing the launcher window and exits with a non-zero code if the command fails.
+func runCommand(name string, args []string) {
+	parse, ok := commands[name]
+	if !ok {
+		ll.Logger.Error(fmt.Sprintf("Unknown command: %s\n", name))
+		os.Exit(2)
+	}
+
+	run, err := parse(args)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Invalid %s command arguments: %v\n", name, err))
+		os.Exit(2)
+	}
+
+	launcher := app.NewLauncher()
+
+	var commandErr error
+	err = wails.Run(&options.App{
+		Title:       "Launcher",
+		StartHidden: true,
+		AssetServer: &assetserver.Options{
+			Assets: assets,
+		},
+		OnStartup: launcher.OnCommandStartup(run, func(err error) {
+			commandErr = err
+		}),
+		Logger: ll.Logger,
+	})
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("Error running command: %v\n", err))
+		os.Exit(1)
+	}
+
+	if commandErr != nil {
+		ll.Logger.Error(fmt.Sprintf("Command %s failed: %v\n", name, commandErr))
+		os.Exit(1)
+	}
+
+	ll.Logger.Info(fmt.Sprintf("Command %s completed\n", name))
+	os.Exit(0)
+}
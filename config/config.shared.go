@@ -13,6 +13,6 @@ const (
 )
 
 const (
-	LauncherPort = "13730" // Port used by the main launcher instance to communicate with the subsequent processes.
-	GamePort     = "13731" // Port used by the game instance to communicate with the main launcher instance.
+	GamePort = "13731" // Port used by the game builds not reading the game endpoint from the environment to communicate with the main launcher instance.
+	PeerPort = "13732" // UDP port used by the launchers on the local network to discover the peer cache files.
 )
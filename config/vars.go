@@ -6,3 +6,6 @@ var LauncherId string
 
 // Logging is a flag that indicates whether logging is enabled.
 var Logging string
+
+// DeepLinkPublicKey is the base64 encoded ed25519 public key of the backend signing the deep links, set during the build process using the -ldflags "-X config.DeepLinkPublicKey=..." flag, the deep link signatures are not verified if empty.
+var DeepLinkPublicKey string
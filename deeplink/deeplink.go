@@ -0,0 +1,235 @@
+// Package deeplink parses and validates the le7el:// deep links opening the launcher from the web pages.
+//
+// A deep link has the form le7el://<action>?appId=<app id>&<parameters>, the action is launch if omitted, e.g. le7el://?appId=<app id>.
+// The actions requiring an app are performed once the app is installed.
+// Only the known parameters are translated into the game launch arguments, the other parameters are passed to the game in the deep link message only,
+// so a web page can not pass arbitrary command line flags to the game.
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Scheme is the URL scheme of the launcher deep links.
+const Scheme = "le7el"
+
+// Deep link actions.
+const (
+	ActionLaunch   = "launch"    // launch the app, the app receives the deep link message once connected
+	ActionInstall  = "install"   // show the app installation in the launcher
+	ActionUpdate   = "update"    // update the app if an update is available
+	ActionOpen     = "open"      // show the app page in the launcher
+	ActionJoin     = "join"      // launch the app and join the space or the server
+	ActionOpenPage = "open-page" // show a launcher page
+)
+
+// Launcher pages opened by the open-page action.
+const (
+	PageLibrary = "library" // the app library
+	PageApp     = "app"     // the app overview, requires the app id
+	PageSdk     = "sdk"     // the SDK used by the app, requires the app id
+)
+
+// Known deep link parameters.
+const (
+	ParamAction    = "action" // the action, alternative to the URL host
+	ParamAppId     = "appId"  // the app id
+	ParamSpace     = "space"  // the space id or name to join
+	ParamServer    = "server" // the server address to join, host:port
+	ParamPage      = "page"   // the launcher page to open
+	ParamSignature = "sig"    // the backend signature of the link, see Verify
+	ParamExpires   = "exp"    // the unix time the signature expires at
+)
+
+// MessageType is the type of the deep link message sent to the game.
+const MessageType = "deep-link"
+
+// maxValueLength is the maximum length of a parameter value.
+const maxValueLength = 1024
+
+var ErrorInvalidLink = errors.New("invalid deep link")
+
+var (
+	paramNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+	spacePattern     = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+)
+
+// Link is a parsed and validated deep link.
+type Link struct {
+	Url    string            `json:"url"`    // the original deep link
+	Action string            `json:"action"` // the requested action
+	AppId  string            `json:"appId"`  // the app id, empty for the open-page action without an app
+	Space  string            `json:"space"`  // the space to join
+	Server string            `json:"server"` // the server address to join
+	Page   string            `json:"page"`   // the launcher page to open
+	Params map[string]string `json:"params"` // the other parameters, passed to the game in the deep link message only
+
+	signature string     // the backend signature of the link
+	expires   int64      // the unix time the signature expires at, zero if the link has no expiry
+	query     url.Values // the parameters covered by the signature
+}
+
+// Message is the deep link message sent to the game.
+type Message struct {
+	Type string `json:"type"` // always MessageType
+	Link
+}
+
+// Parse parses and validates the deep link.
+func Parse(raw string) (*Link, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidLink, err)
+	}
+
+	if !strings.EqualFold(u.Scheme, Scheme) {
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrorInvalidLink, u.Scheme)
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidLink, err)
+	}
+
+	link := &Link{Url: raw, Params: make(map[string]string), query: make(url.Values)}
+
+	// The action is the host of le7el://launch?..., the path of le7el:launch?... or the action parameter.
+	link.Action = strings.Trim(strings.ToLower(u.Host+u.Opaque+u.Path), "/")
+	for name, values := range query {
+		if !paramNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid parameter name %q", ErrorInvalidLink, name)
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("%w: parameter %s is repeated", ErrorInvalidLink, name)
+		}
+
+		value := values[0]
+		if len(value) > maxValueLength || strings.IndexFunc(value, isControl) >= 0 {
+			return nil, fmt.Errorf("%w: invalid value of parameter %s", ErrorInvalidLink, name)
+		}
+
+		// The signature covers all the parameters except itself.
+		if name != ParamSignature {
+			link.query.Set(name, value)
+		}
+
+		switch name {
+		case ParamAction:
+			if link.Action != "" && link.Action != strings.ToLower(value) {
+				return nil, fmt.Errorf("%w: conflicting actions %s and %s", ErrorInvalidLink, link.Action, value)
+			}
+			link.Action = strings.ToLower(value)
+		case ParamAppId:
+			id, err := uuid.FromString(value)
+			if err != nil || id.IsNil() {
+				return nil, fmt.Errorf("%w: invalid app id %q", ErrorInvalidLink, value)
+			}
+			link.AppId = id.String()
+		case ParamSpace:
+			if !spacePattern.MatchString(value) {
+				return nil, fmt.Errorf("%w: invalid space %q", ErrorInvalidLink, value)
+			}
+			link.Space = value
+		case ParamServer:
+			if err = validateServer(value); err != nil {
+				return nil, err
+			}
+			link.Server = value
+		case ParamPage:
+			link.Page = value
+		case ParamSignature:
+			link.signature = value
+		case ParamExpires:
+			link.expires, err = strconv.ParseInt(value, 10, 64)
+			if err != nil || link.expires <= 0 {
+				return nil, fmt.Errorf("%w: invalid expiration time %q", ErrorInvalidLink, value)
+			}
+		default:
+			link.Params[name] = value
+		}
+	}
+
+	if link.Action == "" {
+		link.Action = ActionLaunch
+	}
+
+	if err = link.validate(); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// validate checks the parameters required by the action.
+func (l *Link) validate() error {
+	switch l.Action {
+	case ActionLaunch, ActionInstall, ActionUpdate, ActionOpen:
+		if l.AppId == "" {
+			return fmt.Errorf("%w: %s requires the app id", ErrorInvalidLink, l.Action)
+		}
+	case ActionJoin:
+		if l.AppId == "" {
+			return fmt.Errorf("%w: %s requires the app id", ErrorInvalidLink, l.Action)
+		}
+		if l.Space == "" && l.Server == "" {
+			return fmt.Errorf("%w: %s requires the space or the server", ErrorInvalidLink, l.Action)
+		}
+	case ActionOpenPage:
+		switch l.Page {
+		case PageLibrary:
+		case PageApp, PageSdk:
+			if l.AppId == "" {
+				return fmt.Errorf("%w: page %s requires the app id", ErrorInvalidLink, l.Page)
+			}
+		default:
+			return fmt.Errorf("%w: unknown page %q", ErrorInvalidLink, l.Page)
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrorInvalidLink, l.Action)
+	}
+
+	return nil
+}
+
+// validateServer checks the server address is a host and a port.
+func validateServer(server string) error {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || host == "" || strings.HasPrefix(host, "-") {
+		return fmt.Errorf("%w: invalid server %q", ErrorInvalidLink, server)
+	}
+
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("%w: invalid server port %q", ErrorInvalidLink, port)
+	}
+
+	return nil
+}
+
+// isControl returns true for the control characters not allowed in the parameter values.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// Arguments returns the game launch arguments taking the game straight to the deep link destination, the server address is the first argument as expected by Unreal.
+func (l *Link) Arguments() []string {
+	var args []string
+	if l.Server != "" {
+		args = append(args, l.Server)
+	}
+	if l.Space != "" {
+		args = append(args, "-Space="+l.Space)
+	}
+	return args
+}
+
+// Message returns the deep link message sent to the game.
+func (l *Link) Message() Message {
+	return Message{Type: MessageType, Link: *l}
+}
@@ -0,0 +1,153 @@
+package deeplink
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testAppId = "6f0b1a3e-2c4d-4e5f-8a9b-0c1d2e3f4a5b"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		action string
+		space  string
+		server string
+		page   string
+		params map[string]string
+		err    bool
+	}{
+		{name: "default action", raw: "le7el://?appId=" + testAppId, action: ActionLaunch},
+		{name: "host action", raw: "le7el://install?appId=" + testAppId, action: ActionInstall},
+		{name: "opaque action", raw: "le7el:update?appId=" + testAppId, action: ActionUpdate},
+		{name: "action parameter", raw: "le7el://?action=open&appId=" + testAppId, action: ActionOpen},
+		{name: "action case", raw: "LE7EL://Launch?appId=" + testAppId, action: ActionLaunch},
+		{name: "join space", raw: "le7el://join?appId=" + testAppId + "&space=lobby", action: ActionJoin, space: "lobby"},
+		{name: "join server", raw: "le7el://join?appId=" + testAppId + "&server=example.com:7777", action: ActionJoin, server: "example.com:7777"},
+		{name: "open library page", raw: "le7el://open-page?page=library", action: ActionOpenPage, page: PageLibrary},
+		{name: "open app page", raw: "le7el://open-page?page=app&appId=" + testAppId, action: ActionOpenPage, page: PageApp},
+		{name: "extra parameters", raw: "le7el://launch?appId=" + testAppId + "&mode=vr", action: ActionLaunch, params: map[string]string{"mode": "vr"}},
+		{name: "wrong scheme", raw: "https://launch?appId=" + testAppId, err: true},
+		{name: "missing app id", raw: "le7el://launch", err: true},
+		{name: "invalid app id", raw: "le7el://launch?appId=42", err: true},
+		{name: "nil app id", raw: "le7el://launch?appId=00000000-0000-0000-0000-000000000000", err: true},
+		{name: "unknown action", raw: "le7el://delete?appId=" + testAppId, err: true},
+		{name: "conflicting actions", raw: "le7el://launch?action=install&appId=" + testAppId, err: true},
+		{name: "repeated parameter", raw: "le7el://launch?appId=" + testAppId + "&appId=" + testAppId, err: true},
+		{name: "invalid parameter name", raw: "le7el://launch?appId=" + testAppId + "&-flag=1", err: true},
+		{name: "control character", raw: "le7el://launch?appId=" + testAppId + "&mode=a%0Ab", err: true},
+		{name: "join without destination", raw: "le7el://join?appId=" + testAppId, err: true},
+		{name: "invalid space", raw: "le7el://join?appId=" + testAppId + "&space=a%20b", err: true},
+		{name: "server flag injection", raw: "le7el://join?appId=" + testAppId + "&server=-exec:1", err: true},
+		{name: "server without port", raw: "le7el://join?appId=" + testAppId + "&server=example.com", err: true},
+		{name: "server port out of range", raw: "le7el://join?appId=" + testAppId + "&server=example.com:70000", err: true},
+		{name: "unknown page", raw: "le7el://open-page?page=settings", err: true},
+		{name: "app page without app id", raw: "le7el://open-page?page=sdk", err: true},
+		{name: "invalid expiry", raw: "le7el://launch?appId=" + testAppId + "&exp=soon", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := Parse(tt.raw)
+			if tt.err {
+				if !errors.Is(err, ErrorInvalidLink) {
+					t.Fatalf("Parse(%q) error = %v, want ErrorInvalidLink", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.raw, err)
+			}
+
+			if link.Action != tt.action {
+				t.Errorf("Action = %q, want %q", link.Action, tt.action)
+			}
+			if link.Space != tt.space {
+				t.Errorf("Space = %q, want %q", link.Space, tt.space)
+			}
+			if link.Server != tt.server {
+				t.Errorf("Server = %q, want %q", link.Server, tt.server)
+			}
+			if link.Page != tt.page {
+				t.Errorf("Page = %q, want %q", link.Page, tt.page)
+			}
+
+			params := tt.params
+			if params == nil {
+				params = map[string]string{}
+			}
+			if !reflect.DeepEqual(link.Params, params) {
+				t.Errorf("Params = %v, want %v", link.Params, params)
+			}
+		})
+	}
+}
+
+func TestLinkArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{name: "launch", raw: "le7el://launch?appId=" + testAppId + "&mode=vr", want: nil},
+		{name: "space", raw: "le7el://join?appId=" + testAppId + "&space=lobby", want: []string{"-Space=lobby"}},
+		{name: "server first", raw: "le7el://join?appId=" + testAppId + "&space=lobby&server=10.0.0.1:7777", want: []string{"10.0.0.1:7777", "-Space=lobby"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.raw, err)
+			}
+
+			if got := link.Arguments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Arguments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkMessage(t *testing.T) {
+	link, err := Parse("le7el://join?appId=" + testAppId + "&space=lobby")
+	if err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+
+	message := link.Message()
+	if message.Type != MessageType {
+		t.Errorf("Type = %q, want %q", message.Type, MessageType)
+	}
+	if message.AppId != testAppId || message.Space != "lobby" {
+		t.Errorf("Message() = %+v, want the link fields", message)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name string
+		at   []time.Duration // the offsets of the links from the start
+		want []bool
+	}{
+		{name: "under limit", at: []time.Duration{0, time.Second}, want: []bool{true, true}},
+		{name: "over limit", at: []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, want: []bool{true, true, true, false}},
+		{name: "window slides", at: []time.Duration{0, time.Second, 2 * time.Second, 10 * time.Second}, want: []bool{true, true, true, true}},
+		{name: "rejected not counted", at: []time.Duration{0, 0, 0, 0, 10 * time.Second}, want: []bool{true, true, true, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(3, 10*time.Second)
+			for i, offset := range tt.at {
+				if got := limiter.Allow(start.Add(offset)); got != tt.want[i] {
+					t.Errorf("Allow(#%d at %v) = %v, want %v", i, offset, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
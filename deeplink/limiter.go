@@ -0,0 +1,44 @@
+package deeplink
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of the deep links accepted within a sliding time window, so a web page can not flood the user with the launches or the prompts.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	accepted []time.Time // the times the links have been accepted at within the window
+}
+
+// NewLimiter creates a new Limiter accepting up to limit links within the window.
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow returns true and counts the link if less than the limit links have been accepted within the window before now.
+func (r *Limiter) Allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accepted := r.accepted[:0]
+	for _, t := range r.accepted {
+		if now.Sub(t) < r.window {
+			accepted = append(accepted, t)
+		}
+	}
+	r.accepted = accepted
+
+	if len(r.accepted) >= r.limit {
+		return false
+	}
+
+	r.accepted = append(r.accepted, now)
+
+	return true
+}
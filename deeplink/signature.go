@@ -0,0 +1,71 @@
+package deeplink
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrorUnsigned = errors.New("deep link is not signed")
+var ErrorInvalidSignature = errors.New("invalid deep link signature")
+var ErrorExpired = errors.New("deep link has expired")
+var ErrorMissingExpiry = errors.New("signed deep link has no expiry")
+var ErrorExpiryTooFar = errors.New("deep link expiry is too far in the future")
+
+// MaxSignatureLifetime is the maximum time a signature may be valid for, so a leaked link can not be replayed for long.
+const MaxSignatureLifetime = 24 * time.Hour
+
+// ParsePublicKey decodes the base64 encoded ed25519 public key of the backend.
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size %d", len(b))
+	}
+
+	return b, nil
+}
+
+// IsSigned returns true if the link has the signature parameter.
+func (l *Link) IsSigned() bool {
+	return l.signature != ""
+}
+
+// SignedPayload returns the payload signed by the backend: the scheme, the action and the parameters except the signature sorted by name,
+// e.g. le7el://launch?appId=<app id>&exp=<unix time>.
+func (l *Link) SignedPayload() string {
+	return Scheme + "://" + l.Action + "?" + l.query.Encode()
+}
+
+// Verify checks the link has been signed by the backend with the ed25519 key and the signature has not expired.
+// The signature is the unpadded base64url encoded ed25519 signature of the SignedPayload, the signed payload must have
+// the expiry which is at most MaxSignatureLifetime in the future.
+func (l *Link) Verify(publicKey ed25519.PublicKey, now time.Time) error {
+	if l.signature == "" {
+		return ErrorUnsigned
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(l.signature)
+	if err != nil || !ed25519.Verify(publicKey, []byte(l.SignedPayload()), signature) {
+		return ErrorInvalidSignature
+	}
+
+	if l.expires == 0 {
+		return ErrorMissingExpiry
+	}
+
+	if now.Unix() > l.expires {
+		return ErrorExpired
+	}
+
+	if l.expires > now.Add(MaxSignatureLifetime).Unix() {
+		return ErrorExpiryTooFar
+	}
+
+	return nil
+}
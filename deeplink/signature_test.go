@@ -0,0 +1,96 @@
+package deeplink
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sign returns the link with the signature of its payload appended.
+func sign(t *testing.T, key ed25519.PrivateKey, raw string) string {
+	t.Helper()
+
+	link, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", raw, err)
+	}
+
+	signature := ed25519.Sign(key, []byte(link.SignedPayload()))
+	return raw + "&" + ParamSignature + "=" + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		err  bool
+	}{
+		{name: "valid", key: base64.StdEncoding.EncodeToString(publicKey)},
+		{name: "not base64", key: "not a key", err: true},
+		{name: "wrong size", key: base64.StdEncoding.EncodeToString(publicKey[:16]), err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePublicKey(tt.key)
+			if (err != nil) != tt.err {
+				t.Errorf("ParsePublicKey() error = %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLinkVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	base := "le7el://launch?appId=" + testAppId
+	exp := func(d time.Duration) string {
+		return "&" + ParamExpires + "=" + strconv.FormatInt(now.Add(d).Unix(), 10)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{name: "valid", raw: sign(t, privateKey, base+exp(time.Hour))},
+		{name: "valid at the lifetime limit", raw: sign(t, privateKey, base+exp(MaxSignatureLifetime))},
+		{name: "unsigned", raw: base + exp(time.Hour), want: ErrorUnsigned},
+		{name: "wrong key", raw: sign(t, otherKey, base+exp(time.Hour)), want: ErrorInvalidSignature},
+		{name: "tampered", raw: sign(t, privateKey, base+exp(time.Hour)) + "&space=lobby", want: ErrorInvalidSignature},
+		{name: "garbage signature", raw: base + exp(time.Hour) + "&sig=!!!", want: ErrorInvalidSignature},
+		{name: "missing expiry", raw: sign(t, privateKey, base), want: ErrorMissingExpiry},
+		{name: "expired", raw: sign(t, privateKey, base+exp(-time.Second)), want: ErrorExpired},
+		{name: "expiry too far", raw: sign(t, privateKey, base+exp(MaxSignatureLifetime+time.Minute)), want: ErrorExpiryTooFar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.raw, err)
+			}
+
+			err = link.Verify(publicKey, now)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,17 +2,44 @@
 package events
 
 const (
-	LauncherMetadata         = "launcher-metadata"          // launcher metadata received from the server
-	LauncherUpdateAvailable  = "launcher-update-available"  // update available for launcher, proceed with update
-	LauncherUpdateProgress   = "launcher-update-progress"   // update is in progress, user waiting for download to finish
-	LauncherUpdateFailed     = "launcher-update-failed"     // update failed, but the launcher is still usable, and the user can retry or ignore the update
-	LauncherUpdateDownloaded = "launcher-update-downloaded" // update downloaded, proceed with update
-	LauncherReady            = "launcher-ready"             // launcher is ready to be used
-	LauncherApps             = "launcher-apps"              // launcher apps received from the server
-	LauncherApp              = "launcher-app"               // launcher app received from the server
-	AppUpdateAvailable       = "app-update-available"       // app update available, can update or ignore
-	AppUpdateProgress        = "app-update-progress"        // app update is in progress, user waiting for download to finish
-	AppUpdateExtracting      = "app-update-extracting"      // app update archive downloaded, extracting files
-	AppUpdateFailed          = "app-update-failed"          // app update failed, and the user can retry or ignore the update
-	AppUpdateCompleted       = "app-update-completed"       // app update completed
+	LauncherMetadata              = "launcher-metadata"                // launcher metadata received from the server
+	LauncherUpdateAvailable       = "launcher-update-available"        // update available for launcher, proceed with update
+	LauncherUpdateProgress        = "launcher-update-progress"         // update is in progress, user waiting for download to finish
+	LauncherUpdateFailed          = "launcher-update-failed"           // update failed, but the launcher is still usable, and the user can retry or ignore the update
+	LauncherUpdateDownloaded      = "launcher-update-downloaded"       // update downloaded, proceed with update
+	LauncherReady                 = "launcher-ready"                   // launcher is ready to be used
+	LauncherApps                  = "launcher-apps"                    // launcher apps received from the server
+	LauncherApp                   = "launcher-app"                     // launcher app received from the server
+	LauncherOfflineStatus         = "launcher-offline-status"          // api became unreachable or reachable again, the launcher uses cached metadata while offline
+	LauncherCatalogChanged        = "launcher-catalog-changed"         // catalog refresh found added, removed or changed apps
+	AppUpdateAvailable            = "app-update-available"             // app update available, can update or ignore
+	AppUpdateProgress             = "app-update-progress"              // app update is in progress, user waiting for download to finish
+	AppUpdateExtracting           = "app-update-extracting"            // app update archive downloaded, extracting files
+	AppUpdateFailed               = "app-update-failed"                // app update failed, and the user can retry or ignore the update
+	AppUpdateCompleted            = "app-update-completed"             // app update completed
+	AppComponentProgress          = "app-component-progress"           // app optional component is downloading
+	AppComponentsChanged          = "app-components-changed"           // app optional components have been installed or removed
+	AppMoveProgress               = "app-move-progress"                // app files are being copied to another library folder
+	AppMoveCompleted              = "app-move-completed"               // app has been moved to another library folder
+	AppMoveFailed                 = "app-move-failed"                  // app move failed, the app stays in the source library and the move can be retried
+	AppVerifyProgress             = "app-verify-progress"              // app files are being verified
+	AppVerifyFailed               = "app-verify-failed"                // app files are missing or modified
+	AppInstallationAdded          = "app-installation-added"           // existing app installation has been registered without downloading
+	AppStarted                    = "app-started"                      // app process has been launched
+	AppExited                     = "app-exited"                       // app process has exited normally or has been stopped by the launcher
+	AppCrashed                    = "app-crashed"                      // app process has exited with a non-zero exit code or has been terminated by a signal
+	GameClientConnected           = "game-client-connected"            // game client has connected to the launcher game channel
+	GameClientDisconnected        = "game-client-disconnected"         // game client has disconnected or stopped sending heartbeats
+	GameClientStatus              = "game-client-status"               // game client has reported its status
+	DirectoryLocked               = "directory-locked"                 // app installation or temporary directory is locked by another launcher process
+	DeepLinkConfirmationRequested = "deep-link-confirmation-requested" // deep link asks to start or update an app, the user allows or declines it with ConfirmDeepLink
+	DeepLinkNavigate              = "deep-link-navigate"               // deep link asks the launcher to show a page
+	DeepLinkInstallOffered        = "deep-link-install-offered"        // deep link requested an app which is not installed, the action continues once the app is installed
+	AppExportProgress             = "app-export-progress"              // app files are being written to the package
+	AppExportCompleted            = "app-export-completed"             // app package has been written
+	AppExportFailed               = "app-export-failed"                // app export failed
+	SdkUpdateAvailable            = "sdk-update-available"             // update available for the sdk used by the app
+	SdkUpdateProgress             = "sdk-update-progress"              // sdk used by the app is downloading
+	SdkUpdateFailed               = "sdk-update-failed"                // sdk used by the app failed to install
+	SdkUpdateCompleted            = "sdk-update-completed"             // sdk used by the app has been installed
 )
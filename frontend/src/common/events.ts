@@ -15,6 +15,13 @@ export const events = {
     // Launcher self-update, used in the SelfUpdate component.
     // Update is complete or no update required, launcher is ready to be used, open the app library.
     LauncherReady: "launcher-ready",
+    // Launcher offline mode, used to indicate that the API is unreachable and cached metadata is shown.
+    // Payload: isOffline: boolean
+    LauncherOfflineStatus: "launcher-offline-status",
+    // Launcher app catalog, used in the Library component.
+    // Catalog refresh found added, removed or changed apps.
+    // Payload: { added: AppV2[], removed: AppV2[], changed: AppV2[] }
+    LauncherCatalogChanged: "launcher-catalog-changed",
     // Application update, used in the StatusBar component.
     // Application update is in progress, user waiting for download to finish.
     // Payload: { id: string, progress: number, total: number }
@@ -28,5 +35,99 @@ export const events = {
     // Application update, used in the StatusBar component.
     // Application update completed and application is ready for launch.
     AppUpdateCompleted: "app-update-completed",
+    // Application optional components.
+    // Optional component of the application is downloading.
+    // Payload: { app: AppV2, component: string, progress: number, total: number }
+    AppComponentProgress: "app-component-progress",
+    // Application optional components.
+    // Optional components of the application have been installed or removed.
+    // Payload: { app: AppV2 }
+    AppComponentsChanged: "app-components-changed",
+    // Application move between library folders.
+    // Application files are being copied and verified.
+    // Payload: { id: string, progress: number, total: number }
+    AppMoveProgress: "app-move-progress",
+    // Application move between library folders.
+    // Application has been moved and the source files have been removed.
+    // Payload: { id: string, path: string }
+    AppMoveCompleted: "app-move-completed",
+    // Application move between library folders.
+    // Move failed, the application stays in the source library and the move can be retried.
+    // Payload: { id: string, error: string }
+    AppMoveFailed: "app-move-failed",
+    // Existing application installation.
+    // Application files are being verified.
+    // Payload: { id: string, progress: number, total: number }
+    AppVerifyProgress: "app-verify-progress",
+    // Existing application installation.
+    // Application files are missing or modified, the installation has not been registered.
+    // Payload: { id: string, error: string }
+    AppVerifyFailed: "app-verify-failed",
+    // Existing application installation.
+    // Installation has been verified and registered, the application is ready for launch.
+    // Payload: { id: string, path: string }
+    AppInstallationAdded: "app-installation-added",
+    // Application process.
+    // Application process has been launched.
+    // Payload: { id: string, pid: number, executable: string, startedAt: string }
+    AppStarted: "app-started",
+    // Application process has exited normally or has been stopped by the launcher.
+    // Payload: { id: string, pid: number, startedAt: string, exitedAt: string, exitCode: number, signal: string, stopped: boolean }
+    AppExited: "app-exited",
+    // Application process has exited with a non-zero exit code or has been terminated by a signal.
+    // Payload: { id: string, pid: number, startedAt: string, exitedAt: string, exitCode: number, signal: string, stopped: boolean }
+    AppCrashed: "app-crashed",
+    // Game client channel.
+    // Game client has connected to the launcher.
+    // Payload: { id: string, appId: string, version: string, status: string, details: string, connectedAt: string, lastSeenAt: string }
+    GameClientConnected: "game-client-connected",
+    // Game client has disconnected or stopped sending heartbeats.
+    // Payload: { id: string, appId: string, version: string, status: string, details: string, connectedAt: string, lastSeenAt: string }
+    GameClientDisconnected: "game-client-disconnected",
+    // Game client has reported its status.
+    // Payload: { id: string, appId: string, version: string, status: string, details: string, connectedAt: string, lastSeenAt: string }
+    GameClientStatus: "game-client-status",
+    // Directory lock.
+    // Operation has been refused because another launcher process holds the lock of the directory.
+    // Payload: { path: string, pid: number }
+    DirectoryLocked: "directory-locked",
+    // Deep link.
+    // Deep link asks to start or update an application for the first time, the user allows or declines it with ConfirmDeepLink.
+    // Payload: { requestId: string, link: { url: string, action: string, appId: string, space: string, server: string, page: string, params: Record<string, string> } }
+    DeepLinkConfirmationRequested: "deep-link-confirmation-requested",
+    // Deep link asks the launcher to show a page, the app id is empty for the library page.
+    // Payload: { page: "library" | "app" | "sdk", appId: string }
+    DeepLinkNavigate: "deep-link-navigate",
+    // Deep link requested an application which is not installed. The action continues once the application is installed, DismissDeepLink drops it.
+    // Payload: { url: string, action: string, appId: string, space: string, server: string, page: string, params: Record<string, string> }
+    DeepLinkInstallOffered: "deep-link-install-offered",
+    // Application export.
+    // Application files are being written to the package.
+    // Payload: { id: string, progress: number, total: number }
+    AppExportProgress: "app-export-progress",
+    // Application export.
+    // Package has been written and can be imported by another launcher.
+    // Payload: { id: string, path: string }
+    AppExportCompleted: "app-export-completed",
+    // Application export.
+    // Export failed, no package has been written.
+    // Payload: { id: string, error: string }
+    AppExportFailed: "app-export-failed",
+    // SDK update, used in the StatusBar component.
+    // Update available for the SDK used by the application.
+    // Payload: { id: string, availability: UpdateAvailability }
+    SdkUpdateAvailable: "sdk-update-available",
+    // SDK update, used in the StatusBar component.
+    // SDK used by the application is downloading.
+    // Payload: { app: AppV2, progress: number, total: number }
+    SdkUpdateProgress: "sdk-update-progress",
+    // SDK update, used in the StatusBar component.
+    // SDK installation failed.
+    // Payload: { app: AppV2, error: string }
+    SdkUpdateFailed: "sdk-update-failed",
+    // SDK update, used in the StatusBar component.
+    // SDK installed and ready to be used by the application.
+    // Payload: { app: AppV2 }
+    SdkUpdateCompleted: "sdk-update-completed",
 }
 
@@ -0,0 +1,243 @@
+// Package game implements the channel between the launcher and the game clients.
+//
+// The game client connects to the game endpoint and exchanges newline-delimited JSON messages with the launcher. Every message has a type:
+// the game starts with hello carrying its app id and version, both sides send heartbeats, the game reports its status, the launcher sends the deep links,
+// the session token of the signed-in user, the logout notices, the status requests and the shutdown requests. The game clients sending only {"appId":"..."} are supported as the legacy clients, they receive the deep links only.
+// The session token and the logout notices are sent to the trusted game clients only, i.e. the clients which have presented the secret of their launch in the hello.
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"games.launch.launcher/model"
+	"github.com/gofrs/uuid"
+	"net"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the version of the game channel protocol sent in the launcher hello.
+const ProtocolVersion = 1
+
+// Message types.
+const (
+	TypeHello     = "hello"     // the game client identifies itself, the launcher answers with its protocol version
+	TypeHeartbeat = "heartbeat" // keeps the session alive
+	TypeStatus    = "status"    // the game reports its status, the launcher requests the status with an empty status
+	TypeShutdown  = "shutdown"  // the launcher asks the game to quit
+	TypeDeepLink  = "deep-link" // the launcher passes the deep link to the game, see deeplink.Message
+	TypeSession   = "session"   // the launcher passes the session token of the signed-in user, on connect and whenever the token is renewed
+	TypeLogout    = "logout"    // the user has signed out, the game drops the session token
+)
+
+// Timeouts of the session.
+const (
+	HandshakeTimeout  = 10 * time.Second // the time the game client has to send hello after connecting
+	HeartbeatInterval = 10 * time.Second // the interval the launcher sends the heartbeats at
+	HeartbeatTimeout  = 30 * time.Second // the session is closed if nothing is received from the game client within the timeout
+	WriteTimeout      = 10 * time.Second // the time a message has to be written
+)
+
+// maxMessageSize is the maximum size of a message line.
+const maxMessageSize = 1 << 20
+
+var ErrorInvalidHello = errors.New("invalid game client hello")
+
+// Message is a message exchanged with the game client, the fields not used by the message type are omitted.
+type Message struct {
+	Type     string `json:"type"`               // the message type
+	AppId    string `json:"appId,omitempty"`    // hello: the app id of the game
+	Version  string `json:"version,omitempty"`  // hello: the game version
+	Protocol int    `json:"protocol,omitempty"` // hello: the protocol version of the launcher
+	Status   string `json:"status,omitempty"`   // status: the game status, e.g. loading, menu or playing
+	Details  string `json:"details,omitempty"`  // status: the status details
+	Reason   string `json:"reason,omitempty"`   // shutdown: the reason shown by the game
+	Token    string `json:"token,omitempty"`    // session: the session token (JWT) used to call the API
+	Secret   string `json:"secret,omitempty"`   // hello: the secret the launcher has passed to the launched game process
+}
+
+// Session is a connected game client.
+type Session struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	writeMu sync.Mutex
+	legacy  bool // the game client does not send the typed messages and the heartbeats
+	trusted bool // the game client has presented the secret of its launch, so it may receive the session token
+
+	mu     sync.Mutex
+	client model.GameClient
+}
+
+// NewSession creates a new Session of the connection.
+func NewSession(conn net.Conn) *Session {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxMessageSize)
+
+	return &Session{
+		conn:    conn,
+		scanner: scanner,
+	}
+}
+
+// Handshake reads the hello of the game client and answers with the launcher hello, the session is trusted if the authorize function accepts the app id and the secret of the hello.
+func (s *Session) Handshake(authorize func(appId string, secret string) bool) error {
+	err := s.conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	if err != nil {
+		return err
+	}
+
+	message, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	// The legacy game clients send the app id without the message type.
+	if message.Type != TypeHello && message.Type != "" {
+		return fmt.Errorf("%w: unexpected message %s", ErrorInvalidHello, message.Type)
+	}
+
+	appId, err := uuid.FromString(message.AppId)
+	if err != nil {
+		return fmt.Errorf("%w: invalid app id %q", ErrorInvalidHello, message.AppId)
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("failed to generate connection id: %w", err)
+	}
+
+	now := time.Now()
+	s.legacy = message.Type == ""
+	s.trusted = !s.legacy && message.Secret != "" && authorize != nil && authorize(appId.String(), message.Secret)
+	s.client = model.GameClient{
+		Id:          id.String(),
+		AppId:       appId.String(),
+		Version:     message.Version,
+		ConnectedAt: now,
+		LastSeenAt:  now,
+	}
+
+	if s.legacy {
+		// The legacy game clients never send anything after the app id.
+		return s.conn.SetReadDeadline(time.Time{})
+	}
+
+	return s.Send(Message{Type: TypeHello, AppId: s.client.AppId, Protocol: ProtocolVersion})
+}
+
+// Client returns the game client state.
+func (s *Session) Client() model.GameClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.client
+}
+
+// IsLegacy returns true if the game client does not support the typed messages.
+func (s *Session) IsLegacy() bool {
+	return s.legacy
+}
+
+// IsTrusted returns true if the game client has presented the secret of its launch.
+func (s *Session) IsTrusted() bool {
+	return s.trusted
+}
+
+// read reads the next message.
+func (s *Session) read() (Message, error) {
+	var message Message
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return message, err
+		}
+		return message, net.ErrClosed
+	}
+
+	err := json.Unmarshal(s.scanner.Bytes(), &message)
+	if err != nil {
+		return message, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	return message, nil
+}
+
+// Send writes the message as a JSON line.
+func (s *Session) Send(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	err = s.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.Write(append(b, '\n'))
+	return err
+}
+
+// Run reads the messages until the game client disconnects or stops sending the heartbeats, the status is recorded before the messages are passed to the handler.
+// The heartbeats are sent to the game client meanwhile.
+func (s *Session) Run(handler func(Message)) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	if !s.legacy {
+		go s.sendHeartbeats(done)
+	}
+
+	for {
+		if !s.legacy {
+			err := s.conn.SetReadDeadline(time.Now().Add(HeartbeatTimeout))
+			if err != nil {
+				return err
+			}
+		}
+
+		message, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.client.LastSeenAt = time.Now()
+		if message.Type == TypeStatus {
+			s.client.Status = message.Status
+			s.client.Details = message.Details
+		}
+		s.mu.Unlock()
+
+		if message.Type != TypeHeartbeat {
+			handler(message)
+		}
+	}
+}
+
+// sendHeartbeats sends the heartbeats until done is closed, the connection is closed if the heartbeat can not be written.
+func (s *Session) sendHeartbeats(done chan struct{}) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.Send(Message{Type: TypeHeartbeat}); err != nil {
+				_ = s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// Close closes the connection.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
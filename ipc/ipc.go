@@ -0,0 +1,191 @@
+// Package ipc implements the protocol between the launcher instances: the subsequent launcher process, e.g. opened by a deep link or from the command line,
+// sends a command to the first launcher instance and prints its response.
+//
+// Every message is a frame of the 4 byte big endian length followed by the JSON encoded message. The requests carry the protocol version
+// and the per-user secret token read from a file accessible by the user only, so other local users and processes can not send the commands.
+package ipc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version is the protocol version, the requests with another version are rejected.
+const Version = 1
+
+// TokenFile is the file in the launcher config directory keeping the secret token.
+const TokenFile = "instance.token"
+
+// MaxFrameSize is the maximum size of a message.
+const MaxFrameSize = 1 << 20
+
+// Commands sent to the first launcher instance.
+const (
+	CommandPing     = "ping"      // check the launcher is running
+	CommandShow     = "show"      // bring the launcher window to the front
+	CommandDeepLink = "deep-link" // process the deep link passed as the argument
+	CommandLaunch   = "launch"    // launch the app with the id passed as the argument
+	CommandStop     = "stop"      // stop the app with the id passed as the argument
+	CommandRunning  = "running"   // list the running apps
+)
+
+var ErrorFrameTooLarge = errors.New("frame is too large")
+var ErrorUnauthorized = errors.New("invalid token")
+var ErrorUnsupportedVersion = errors.New("unsupported protocol version")
+
+// Request is a command sent to the first launcher instance.
+type Request struct {
+	Version   int      `json:"version"`   // the protocol version
+	Token     string   `json:"token"`     // the secret token
+	Command   string   `json:"command"`   // the command, one of the Command constants
+	Arguments []string `json:"arguments"` // the command arguments
+}
+
+// Response is the result of the command.
+type Response struct {
+	Version int             `json:"version"` // the protocol version
+	Ok      bool            `json:"ok"`      // has the command succeeded
+	Message string          `json:"message"` // the message printed by the subsequent instance, the error if the command has failed
+	Data    json.RawMessage `json:"data"`    // the command result, e.g. the running apps
+}
+
+// Ok returns a successful response with the message and the optional data.
+func Ok(message string, data any) Response {
+	response := Response{Version: Version, Ok: true, Message: message}
+	if data != nil {
+		response.Data, _ = json.Marshal(data)
+	}
+	return response
+}
+
+// Fail returns a failed response with the error.
+func Fail(err error) Response {
+	return Response{Version: Version, Message: err.Error()}
+}
+
+// WriteFrame writes the message as a length-prefixed JSON frame.
+func WriteFrame(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	if len(b) > MaxFrameSize {
+		return ErrorFrameTooLarge
+	}
+
+	frame := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[4:], b)
+
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a length-prefixed JSON frame into the message.
+func ReadFrame(r io.Reader, v any) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return ErrorFrameTooLarge
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	return nil
+}
+
+// Authorize checks the request version and token.
+func (r *Request) Authorize(token string) error {
+	if r.Version != Version {
+		return fmt.Errorf("%w %d, expected %d", ErrorUnsupportedVersion, r.Version, Version)
+	}
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(r.Token), []byte(token)) != 1 {
+		return ErrorUnauthorized
+	}
+
+	return nil
+}
+
+// Call sends the request with the protocol version and the token and reads the response.
+func Call(conn io.ReadWriter, token string, command string, args ...string) (*Response, error) {
+	err := WriteFrame(conn, Request{Version: Version, Token: token, Command: command, Arguments: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	response := &Response{}
+	err = ReadFrame(conn, response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return response, nil
+}
+
+// ReadToken reads the secret token from the directory.
+func ReadToken(dir string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, TokenFile))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// CreateToken writes a new random secret token into the directory, the file is readable by the user only.
+// On Windows the file inherits the permissions of the user config directory.
+func CreateToken(dir string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create token dir: %w", err)
+	}
+
+	// Write a temporary file readable by the user only and rename it, so a subsequent instance never reads a partially written token.
+	tmp, err := os.CreateTemp(dir, TokenFile+".*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(token); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), filepath.Join(dir, TokenFile)); err != nil {
+		return "", fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	return token, nil
+}
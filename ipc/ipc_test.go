@@ -0,0 +1,180 @@
+package ipc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		request Request
+	}{
+		{name: "no arguments", request: Request{Version: Version, Token: "secret", Command: CommandPing}},
+		{name: "arguments", request: Request{Version: Version, Token: "secret", Command: CommandDeepLink, Arguments: []string{"le7el://launch?appId=1"}}},
+		{name: "unicode", request: Request{Version: Version, Command: CommandLaunch, Arguments: []string{"ψ\n\"quoted\""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteFrame(&buf, tt.request); err != nil {
+				t.Fatalf("WriteFrame() error = %v", err)
+			}
+
+			if size := binary.BigEndian.Uint32(buf.Bytes()); int(size) != buf.Len()-4 {
+				t.Errorf("frame header = %d, want %d", size, buf.Len()-4)
+			}
+
+			var got Request
+			if err := ReadFrame(&buf, &got); err != nil {
+				t.Fatalf("ReadFrame() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.request) {
+				t.Errorf("ReadFrame() = %+v, want %+v", got, tt.request)
+			}
+		})
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	frame := func(size uint32, body string) []byte {
+		b := make([]byte, 4, 4+len(body))
+		binary.BigEndian.PutUint32(b, size)
+		return append(b, body...)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  error // the expected error, any error if nil
+	}{
+		{name: "empty", input: nil, want: io.EOF},
+		{name: "short header", input: []byte{0, 0}, want: io.ErrUnexpectedEOF},
+		{name: "too large", input: frame(MaxFrameSize+1, ""), want: ErrorFrameTooLarge},
+		{name: "short body", input: frame(10, "{}"), want: io.ErrUnexpectedEOF},
+		{name: "invalid json", input: frame(3, "{x}")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request Request
+			err := ReadFrame(bytes.NewReader(tt.input), &request)
+			if err == nil {
+				t.Fatal("ReadFrame() error = nil, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ReadFrame() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFrameTooLarge(t *testing.T) {
+	request := Request{Version: Version, Arguments: []string{string(make([]byte, MaxFrameSize))}}
+	if err := WriteFrame(io.Discard, request); !errors.Is(err, ErrorFrameTooLarge) {
+		t.Errorf("WriteFrame() error = %v, want ErrorFrameTooLarge", err)
+	}
+}
+
+func TestRequestAuthorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		request Request
+		token   string
+		want    error
+	}{
+		{name: "valid", request: Request{Version: Version, Token: "secret"}, token: "secret"},
+		{name: "wrong token", request: Request{Version: Version, Token: "guess"}, token: "secret", want: ErrorUnauthorized},
+		{name: "missing token", request: Request{Version: Version}, token: "secret", want: ErrorUnauthorized},
+		{name: "empty server token", request: Request{Version: Version}, token: "", want: ErrorUnauthorized},
+		{name: "wrong version", request: Request{Version: Version + 1, Token: "secret"}, token: "secret", want: ErrorUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Authorize(tt.token)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Authorize() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCall(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		var request Request
+		if err := ReadFrame(server, &request); err != nil {
+			return
+		}
+
+		response := Ok("pong", request.Arguments)
+		if err := request.Authorize("secret"); err != nil {
+			response = Fail(err)
+		}
+		_ = WriteFrame(server, response)
+	}()
+
+	response, err := Call(client, "secret", CommandPing, "a", "b")
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	if !response.Ok || response.Message != "pong" || response.Version != Version {
+		t.Errorf("Call() = %+v, want ok pong", response)
+	}
+	if string(response.Data) != `["a","b"]` {
+		t.Errorf("Call() data = %s, want the arguments", response.Data)
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	dir := t.TempDir()
+
+	token, err := CreateToken(dir)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("CreateToken() token length = %d, want 64", len(token))
+	}
+
+	read, err := ReadToken(dir)
+	if err != nil {
+		t.Fatalf("ReadToken() error = %v", err)
+	}
+	if read != token {
+		t.Errorf("ReadToken() = %q, want %q", read, token)
+	}
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(filepath.Join(dir, TokenFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("token file mode = %v, want 0600", fi.Mode().Perm())
+		}
+	}
+
+	next, err := CreateToken(dir)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if next == token {
+		t.Error("CreateToken() returned the same token twice")
+	}
+}
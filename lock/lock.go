@@ -0,0 +1,159 @@
+// Package lock provides the cross-process advisory locks of the directories shared by several launcher copies, e.g. the app installation directories and the temporary download directories.
+// A lock is a file next to the locked directory holding the PID of the owning process, the locks of the exited processes are reclaimed automatically.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ll "games.launch.launcher/logger"
+)
+
+// Dir is the directory next to the locked directories keeping the lock files.
+const Dir = ".locks"
+
+// incompleteLockAge is the age after which a lock file without the owner is considered abandoned, the owner is written right after the lock file is created.
+const incompleteLockAge = 10 * time.Second
+
+var ErrorLocked = errors.New("locked by another process")
+
+// LockedError is returned when the directory is locked by another process.
+type LockedError struct {
+	Path     string // the locked directory
+	Pid      int    // the PID of the process holding the lock
+	Hostname string // the host of the process holding the lock
+}
+
+// Error implements the error interface.
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is locked by process %d on %s", e.Path, e.Pid, e.Hostname)
+}
+
+// Unwrap returns ErrorLocked, so the error is matched with errors.Is.
+func (e *LockedError) Unwrap() error {
+	return ErrorLocked
+}
+
+// owner is the content of the lock file.
+type owner struct {
+	Pid        int       `json:"pid"`        // the PID of the process holding the lock
+	Hostname   string    `json:"hostname"`   // the host of the process, the PID can not be checked on another host sharing the directory
+	AcquiredAt time.Time `json:"acquiredAt"` // the time the lock has been acquired
+}
+
+// Lock is an acquired lock of a directory.
+type Lock struct {
+	Path     string // the locked directory
+	lockPath string
+}
+
+// held are the lock files held by this process, the lock file with the PID of this process which is not held is left by a previous process with the same PID.
+var (
+	held   = make(map[string]bool)
+	heldMu sync.Mutex
+)
+
+// getLockPath returns the lock file of the directory.
+func getLockPath(dir string) string {
+	dir = filepath.Clean(dir)
+	return filepath.Join(filepath.Dir(dir), Dir, filepath.Base(dir)+".lock")
+}
+
+// Acquire locks the directory, a *LockedError is returned if another process holds the lock.
+func Acquire(dir string) (*Lock, error) {
+	lockPath := getLockPath(dir)
+
+	err := os.MkdirAll(filepath.Dir(lockPath), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock dir: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	heldMu.Lock()
+	defer heldMu.Unlock()
+
+	if held[lockPath] {
+		return nil, &LockedError{Path: dir, Pid: os.Getpid(), Hostname: hostname}
+	}
+
+	// The second attempt follows the removal of a stale lock.
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			err = json.NewEncoder(f).Encode(owner{Pid: os.Getpid(), Hostname: hostname, AcquiredAt: time.Now()})
+			_ = f.Close()
+			if err != nil {
+				_ = os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file: %w", err)
+			}
+
+			held[lockPath] = true
+			return &Lock{Path: dir, lockPath: lockPath}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		o, stale := readOwner(lockPath, hostname)
+		if !stale {
+			return nil, &LockedError{Path: dir, Pid: o.Pid, Hostname: o.Hostname}
+		}
+
+		ll.Logger.Warning(fmt.Sprintf("reclaiming stale lock %s of process %d\n", lockPath, o.Pid))
+		err = os.Remove(lockPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+		}
+	}
+
+	return nil, &LockedError{Path: dir, Hostname: hostname}
+}
+
+// readOwner reads the owner of the lock file and checks if the lock is stale, i.e. the owner has exited without releasing it.
+func readOwner(lockPath string, hostname string) (owner, bool) {
+	var o owner
+
+	fi, err := os.Stat(lockPath)
+	if err != nil {
+		// The lock has been released meanwhile.
+		return o, os.IsNotExist(err)
+	}
+
+	b, err := os.ReadFile(lockPath)
+	if err != nil || json.Unmarshal(b, &o) != nil || o.Pid == 0 {
+		// The owner may be writing the lock file right now.
+		return o, time.Since(fi.ModTime()) > incompleteLockAge
+	}
+
+	if o.Hostname != hostname {
+		return o, false
+	}
+
+	if o.Pid == os.Getpid() {
+		// The lock has been left by a previous process with the same PID, the locks of this process are checked before.
+		return o, true
+	}
+
+	return o, !isProcessRunning(o.Pid)
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+
+	delete(held, l.lockPath)
+
+	err := os.Remove(l.lockPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ll "games.launch.launcher/logger"
+	"github.com/wailsapp/wails/v2/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// The reclaimed stale locks are logged.
+	ll.Logger = logger.NewDefaultLogger()
+	os.Exit(m.Run())
+}
+
+// exitedPid returns the PID of a process which has exited.
+func exitedPid(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run child process: %v", err)
+	}
+
+	return cmd.ProcessState.Pid()
+}
+
+// writeLockFile writes the lock file of the directory with the given content and modification time.
+func writeLockFile(t *testing.T, dir string, content []byte, modTime time.Time) {
+	t.Helper()
+
+	lockPath := getLockPath(dir)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lockPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(lockPath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	hostname, _ := os.Hostname()
+	now := time.Now()
+
+	encode := func(o owner) []byte {
+		b, err := json.Marshal(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		content []byte    // the existing lock file, none if nil
+		modTime time.Time // the modification time of the existing lock file
+		pid     int       // the PID of the holder reported by the LockedError, the lock is acquired if zero
+	}{
+		{name: "unlocked"},
+		{name: "exited owner", content: encode(owner{Pid: exitedPid(t), Hostname: hostname, AcquiredAt: now}), modTime: now},
+		{name: "previous process with the same pid", content: encode(owner{Pid: os.Getpid(), Hostname: hostname, AcquiredAt: now}), modTime: now},
+		{name: "running owner", content: encode(owner{Pid: os.Getppid(), Hostname: hostname, AcquiredAt: now}), modTime: now, pid: os.Getppid()},
+		{name: "owner on another host", content: encode(owner{Pid: exitedPid(t), Hostname: hostname + "-other", AcquiredAt: now}), modTime: now, pid: -1},
+		{name: "incomplete lock being written", content: []byte{}, modTime: now, pid: -1},
+		{name: "abandoned incomplete lock", content: []byte("{"), modTime: now.Add(-2 * incompleteLockAge)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "app")
+			if tt.content != nil {
+				writeLockFile(t, dir, tt.content, tt.modTime)
+			}
+
+			lk, err := Acquire(dir)
+			if tt.pid != 0 {
+				var lockedErr *LockedError
+				if !errors.As(err, &lockedErr) || !errors.Is(err, ErrorLocked) {
+					t.Fatalf("Acquire() error = %v, want LockedError", err)
+				}
+				if tt.pid > 0 && lockedErr.Pid != tt.pid {
+					t.Errorf("LockedError.Pid = %d, want %d", lockedErr.Pid, tt.pid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Acquire() error = %v", err)
+			}
+			defer lk.Release()
+
+			var o owner
+			b, err := os.ReadFile(getLockPath(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal(b, &o); err != nil {
+				t.Fatal(err)
+			}
+			if o.Pid != os.Getpid() || o.Hostname != hostname {
+				t.Errorf("lock owner = %+v, want pid %d on %s", o, os.Getpid(), hostname)
+			}
+		})
+	}
+}
+
+func TestAcquireHeld(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+
+	lk, err := Acquire(dir)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// The lock held by this process is never reclaimed as stale.
+	var lockedErr *LockedError
+	if _, err = Acquire(dir); !errors.As(err, &lockedErr) || lockedErr.Pid != os.Getpid() {
+		t.Fatalf("second Acquire() error = %v, want LockedError of this process", err)
+	}
+
+	if err = lk.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err = os.Stat(getLockPath(dir)); !os.IsNotExist(err) {
+		t.Errorf("lock file exists after Release(), stat error = %v", err)
+	}
+
+	lk, err = Acquire(dir)
+	if err != nil {
+		t.Fatalf("Acquire() after Release() error = %v", err)
+	}
+	_ = lk.Release()
+}
+
+func TestGetLockPath(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: filepath.Join("library", "app"), want: filepath.Join("library", Dir, "app.lock")},
+		{dir: filepath.Join("library", "app") + string(filepath.Separator), want: filepath.Join("library", Dir, "app.lock")},
+		{dir: filepath.Join("library", ".tmp"), want: filepath.Join("library", Dir, ".tmp.lock")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := getLockPath(tt.dir); got != tt.want {
+				t.Errorf("getLockPath(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code of a running process.
+const stillActive = 259
+
+// isProcessRunning returns true if the process with the given PID is running.
+func isProcessRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process of another user is running but can not be opened.
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer func() {
+		_ = windows.CloseHandle(h)
+	}()
+
+	var code uint32
+	err = windows.GetExitCodeProcess(h, &code)
+	if err != nil {
+		return true
+	}
+
+	return code == stillActive
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"games.launch.launcher/app"
 	"games.launch.launcher/config"
+	"games.launch.launcher/ipc"
 	ll "games.launch.launcher/logger"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/logger"
@@ -88,11 +89,44 @@ func main() {
 		ll.Logger = logger.NewDefaultLogger()
 	}
 
+	// Separate launcher instances use their own config directories and endpoints, e.g. to test the peer cache with several launchers on one machine.
+	if separateInstance := os.Getenv("LE7EL_INSTANCE"); separateInstance != "" {
+		if err := app.SetSeparateInstance(separateInstance); err != nil {
+			ll.Logger.Error(fmt.Sprintf("Invalid LE7EL_INSTANCE: %v\n", err))
+			os.Exit(2)
+		}
+	}
+
 	var err error
 
-	// Attempt to connect to the single instance port, failure means this is the first instance.
-	conn, err := net.DialTimeout("tcp", "127.0.0.1:"+config.LauncherPort, time.Second)
+	// Run the command line command without the UI, deep links are passed as the first argument otherwise.
+	var remoteArgs []string
+	remote := false
+	if len(os.Args) > 1 {
+		if _, ok := commands[os.Args[1]]; ok {
+			runCommand(os.Args[1], os.Args[2:])
+			return
+		}
+
+		// The commands controlling the running launcher are sent to the first instance.
+		if parse, ok := remoteCommands[os.Args[1]]; ok {
+			remoteArgs, err = parse(os.Args[2:])
+			if err != nil {
+				ll.Logger.Error(fmt.Sprintf("Invalid %s command arguments: %v\n", os.Args[1], err))
+				os.Exit(2)
+			}
+			remote = true
+		}
+	}
+
+	// Attempt to connect to the single instance endpoint, failure means this is the first instance.
+	conn, err := app.DialFirstInstance(time.Second)
 	if err != nil {
+		if remote {
+			ll.Logger.Error(fmt.Sprintf("Launcher is not running: %v\n", err))
+			os.Exit(1)
+		}
+
 		ll.Logger.Print(fmt.Sprintf("No other instance found, starting the main instance: %v\n", err))
 
 		// Create a new launcher application instance.
@@ -128,6 +162,9 @@ func main() {
 		if err != nil {
 			ll.Logger.Error(fmt.Sprintf("Error running application: %v\n", err))
 		}
+	} else if remote {
+		// Send the command to the first instance, print the response then exit.
+		sendCommand(conn, os.Args[1], remoteArgs)
 	} else {
 		// Start the subsequent instance and send the deep link to the first instance then exit.
 		startSubsequentInstance(conn)
@@ -143,25 +180,14 @@ func startSubsequentInstance(conn net.Conn) {
 	}
 
 	if deepLink == "" {
-		ll.Logger.Print("No deep link found, exiting subsequent instance\n")
-		os.Exit(0)
-	}
-
-	ll.Logger.Print(fmt.Sprintf("Starting subsequent instance with args: %v\n", deepLink))
-
-	// Send the deep link to the first instance
-	_, err := conn.Write([]byte(deepLink))
-	if err != nil {
-		ll.Logger.Error(fmt.Sprintf("Error sending deep link: %v\n", err))
+		// Bring the window of the main instance to the front instead of starting another one.
+		ll.Logger.Print("No deep link found, showing the main instance\n")
+		sendCommand(conn, ipc.CommandShow, nil)
 		return
 	}
 
-	err = conn.Close()
-	if err != nil {
-		ll.Logger.Error(fmt.Sprintf("Error closing connection: %v\n", err))
-		return
-	}
+	ll.Logger.Print(fmt.Sprintf("Starting subsequent instance with args: %v\n", deepLink))
 
-	// Exit the subsequent instance after sending the deep link.
-	os.Exit(0)
+	// Send the deep link to the first instance and exit.
+	sendCommand(conn, ipc.CommandDeepLink, []string{deepLink})
 }
@@ -0,0 +1,226 @@
+// Package manifest provides the integrity manifest of an installed app, listing the app files with their sizes and SHA-256 hashes, used to verify the files after they have been moved, copied or restored.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	ll "games.launch.launcher/logger"
+	"games.launch.launcher/utils"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the manifest file in the app installation directory.
+const FileName = ".manifest.json"
+
+// File is a single file listed in the manifest.
+type File struct {
+	Path string `json:"path"` // the file path relative to the app installation directory, slash separated
+	Size int64  `json:"size"` // the file size in bytes
+	Hash string `json:"hash"` // the hex encoded SHA-256 hash of the file contents
+}
+
+// Manifest lists the files of an installed app release.
+type Manifest struct {
+	AppId   string `json:"appId,omitempty"`   // the id of the app
+	Version string `json:"version,omitempty"` // the installed release version
+	Files   []File `json:"files"`             // the app files sorted by path
+}
+
+// Size returns the total size of the files listed in the manifest.
+func (m *Manifest) Size() uint64 {
+	var size uint64
+	for _, f := range m.Files {
+		size += uint64(f.Size)
+	}
+	return size
+}
+
+// IsIgnored reports if the file is a launcher metadata file in the root of the app directory, e.g. the .version file, which are not listed in the manifest.
+func IsIgnored(path string) bool {
+	return !strings.Contains(path, "/") && strings.HasPrefix(path, ".")
+}
+
+// ListFiles returns the slash separated paths of the app files in the given directory relative to the directory, sorted by path.
+func ListFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if IsIgnored(rel) {
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to list files in %s: %v\n", dir, err))
+		return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// HashFile returns the hex encoded SHA-256 hash and the size of the file, the file contents are also written to the optional writer, e.g. to track the progress.
+func HashFile(path string, w io.Writer) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	var dst io.Writer = h
+	if w != nil {
+		dst = io.MultiWriter(h, w)
+	}
+
+	n, err := io.Copy(dst, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Build hashes the app files in the given directory and returns the manifest listing them, the progress callback receives the number of hashed bytes.
+func Build(dir string, progress func(current uint64, total uint64)) (*Manifest, error) {
+	paths, err := ListFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var total uint64
+	for _, path := range paths {
+		fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file %s: %w", path, err)
+		}
+		total += uint64(fi.Size())
+	}
+
+	counter := &progressWriter{total: total, progress: progress}
+
+	m := &Manifest{Files: make([]File, 0, len(paths))}
+	for _, path := range paths {
+		hash, size, err := HashFile(filepath.Join(dir, filepath.FromSlash(path)), counter)
+		if err != nil {
+			ll.Logger.Error(fmt.Sprintf("failed to hash file %s: %v\n", path, err))
+			return nil, fmt.Errorf("failed to hash file %s: %w", path, err)
+		}
+
+		m.Files = append(m.Files, File{Path: path, Size: size, Hash: hash})
+	}
+
+	return m, nil
+}
+
+// Verify checks the files listed in the manifest against the files in the given directory and returns the paths of the missing or modified files.
+func Verify(dir string, m *Manifest, progress func(current uint64, total uint64)) ([]string, error) {
+	counter := &progressWriter{total: m.Size(), progress: progress}
+
+	var invalid []string
+	for _, file := range m.Files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				invalid = append(invalid, file.Path)
+				counter.skip(uint64(file.Size))
+				continue
+			}
+			return nil, fmt.Errorf("failed to stat file %s: %w", file.Path, err)
+		}
+
+		if fi.Size() != file.Size {
+			invalid = append(invalid, file.Path)
+			counter.skip(uint64(file.Size))
+			continue
+		}
+
+		hash, _, err := HashFile(path, counter)
+		if err != nil {
+			ll.Logger.Error(fmt.Sprintf("failed to hash file %s: %v\n", file.Path, err))
+			return nil, fmt.Errorf("failed to hash file %s: %w", file.Path, err)
+		}
+
+		if !strings.EqualFold(hash, file.Hash) {
+			invalid = append(invalid, file.Path)
+		}
+	}
+
+	return invalid, nil
+}
+
+// Read reads the manifest from the given app directory, os.ErrNotExist is returned if the directory has no manifest.
+func Read(dir string) (*Manifest, error) {
+	m := &Manifest{}
+	err := utils.ReadJSONFile(filepath.Join(dir, FileName), m)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Write writes the manifest to the given app directory.
+func Write(dir string, m *Manifest) error {
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+
+	err := utils.WriteJSONFile(filepath.Join(dir, FileName), m, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// progressWriter reports the number of bytes written across multiple files.
+type progressWriter struct {
+	current  uint64
+	total    uint64
+	progress func(current uint64, total uint64)
+}
+
+// Write implements the io.Writer interface, triggering the progress callback when data is written.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.skip(uint64(len(p)))
+	return len(p), nil
+}
+
+// skip advances the progress without writing data, e.g. for missing files.
+func (w *progressWriter) skip(n uint64) {
+	w.current += n
+	if w.progress != nil {
+		w.progress(w.current, w.total)
+	}
+}
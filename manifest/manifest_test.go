@@ -0,0 +1,188 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	ll "games.launch.launcher/logger"
+	"github.com/wailsapp/wails/v2/pkg/logger"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The read and hash failures are logged.
+	ll.Logger = logger.NewDefaultLogger()
+	os.Exit(m.Run())
+}
+
+// testFiles are the app files written by writeFiles, the root dot files are the launcher metadata files.
+var testFiles = map[string]string{
+	"Game.exe":              "executable",
+	"Content/Paks/game.pak": "package contents",
+	"Content/.keep":         "",
+	".version":              "1.0.0",
+	FileName:                "{}",
+}
+
+// writeFiles writes the files into a new directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestIsIgnored(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: ".version", want: true},
+		{path: FileName, want: true},
+		{path: "Game.exe", want: false},
+		{path: "Content/.keep", want: false},
+		{path: "Content/game.pak", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsIgnored(tt.path); got != tt.want {
+				t.Errorf("IsIgnored(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := writeFiles(t, testFiles)
+
+	var current, total uint64
+	m, err := Build(dir, func(c uint64, t uint64) {
+		current, total = c, t
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := []File{
+		{Path: "Content/.keep", Size: 0, Hash: hash("")},
+		{Path: "Content/Paks/game.pak", Size: int64(len(testFiles["Content/Paks/game.pak"])), Hash: hash(testFiles["Content/Paks/game.pak"])},
+		{Path: "Game.exe", Size: int64(len(testFiles["Game.exe"])), Hash: hash(testFiles["Game.exe"])},
+	}
+	if !reflect.DeepEqual(m.Files, want) {
+		t.Errorf("Build() files = %+v, want %+v", m.Files, want)
+	}
+
+	if total != m.Size() || current != total {
+		t.Errorf("progress = %d/%d, want %d/%d", current, total, m.Size(), m.Size())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(dir string) error
+		want   []string
+	}{
+		{name: "unchanged", change: func(string) error { return nil }},
+		{name: "modified", change: func(dir string) error {
+			// The same size, so only the hash differs.
+			return os.WriteFile(filepath.Join(dir, "Game.exe"), []byte("EXECUTABLE"), 0644)
+		}, want: []string{"Game.exe"}},
+		{name: "resized", change: func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, "Content", "Paks", "game.pak"), []byte("truncated"), 0644)
+		}, want: []string{"Content/Paks/game.pak"}},
+		{name: "missing", change: func(dir string) error {
+			return os.Remove(filepath.Join(dir, "Content", ".keep"))
+		}, want: []string{"Content/.keep"}},
+		{name: "extra file", change: func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, "Saved.sav"), []byte("save"), 0644)
+		}},
+		{name: "metadata changed", change: func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, ".version"), []byte("2.0.0"), 0644)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, testFiles)
+
+			m, err := Build(dir, nil)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if err = tt.change(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			var current, total uint64
+			invalid, err := Verify(dir, m, func(c uint64, t uint64) {
+				current, total = c, t
+			})
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(invalid, tt.want) {
+				t.Errorf("Verify() = %v, want %v", invalid, tt.want)
+			}
+
+			// The progress reaches the total even if the files are skipped.
+			if current != total || total != m.Size() {
+				t.Errorf("progress = %d/%d, want %d/%d", current, total, m.Size(), m.Size())
+			}
+		})
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Read(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Read() error = %v, want os.ErrNotExist", err)
+	}
+
+	m := &Manifest{AppId: "app", Version: "1.0.0", Files: []File{
+		{Path: "b", Size: 1, Hash: hash("b")},
+		{Path: "a", Size: 1, Hash: hash("a")},
+	}}
+	if err := Write(dir, m); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	read, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// The files are written sorted by path.
+	if read.Files[0].Path != "a" || !reflect.DeepEqual(read, m) {
+		t.Errorf("Read() = %+v, want %+v", read, m)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Read(dir); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Read() of a corrupted manifest error = %v, want a decode error", err)
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	sm "dev.hackerman.me/artheon/veverse-shared/model"
+)
+
+// CatalogDiff describes the changes of the launcher app catalog found by a catalog refresh.
+type CatalogDiff struct {
+	Added   []sm.AppV2 `json:"added"`   // apps that were not in the catalog before the refresh
+	Removed []sm.AppV2 `json:"removed"` // apps that are no longer in the catalog
+	Changed []sm.AppV2 `json:"changed"` // apps which metadata has changed since the previous refresh
+}
+
+// IsEmpty returns true if the refresh did not find any changes.
+func (d *CatalogDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
@@ -0,0 +1,10 @@
+package model
+
+// AppComponent is an optional component of an app release, e.g. a language pack, high resolution textures or editor tools.
+type AppComponent struct {
+	Id            string `json:"id"`            // the component tag
+	Size          uint64 `json:"size"`          // the download size of the component files in the release, used to estimate the required disk space
+	InstalledSize uint64 `json:"installedSize"` // the disk size of the installed component files
+	Available     bool   `json:"available"`     // is the component available in the latest release
+	Installed     bool   `json:"installed"`     // is the component selected and installed
+}
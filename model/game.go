@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// GameClient is a game client connected to the launcher game channel.
+type GameClient struct {
+	Id          string    `json:"id"`          // the connection id
+	AppId       string    `json:"appId"`       // the app id sent by the game client
+	Version     string    `json:"version"`     // the game version sent by the game client
+	Status      string    `json:"status"`      // the last status reported by the game client, e.g. loading or playing
+	Details     string    `json:"details"`     // the details of the last status
+	ConnectedAt time.Time `json:"connectedAt"` // the time the game client has connected
+	LastSeenAt  time.Time `json:"lastSeenAt"`  // the time the last message has been received from the game client
+}
@@ -0,0 +1,11 @@
+package model
+
+// ExistingInstallation is an app installation found in a directory which is not known to the launcher, e.g. restored from a backup or copied from another machine.
+type ExistingInstallation struct {
+	Id           string   `json:"id"`           // the identified app id
+	Name         string   `json:"name"`         // the app name, empty if the app metadata is not available
+	Version      string   `json:"version"`      // the installed release version, empty if unknown
+	Path         string   `json:"path"`         // the installation directory
+	HasManifest  bool     `json:"hasManifest"`  // does the directory contain the integrity manifest used to verify the files
+	InvalidFiles []string `json:"invalidFiles"` // the missing or modified files found by the verification
+}
@@ -0,0 +1,10 @@
+package model
+
+// Library is a library folder where the apps are installed.
+type Library struct {
+	Path       string   `json:"path"`       // the library root directory
+	Default    bool     `json:"default"`    // is the library used for new installations by default
+	FreeSpace  uint64   `json:"freeSpace"`  // the free disk space available in the library
+	TotalSpace uint64   `json:"totalSpace"` // the total disk space of the library drive
+	Apps       []string `json:"apps"`       // ids of the apps installed into the library
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// RunningApp is an app process launched by the launcher.
+type RunningApp struct {
+	Id         string    `json:"id"`         // the app id
+	Pid        int       `json:"pid"`        // the process id
+	Executable string    `json:"executable"` // the app executable path
+	StartedAt  time.Time `json:"startedAt"`  // the time the process has been started
+}
+
+// AppExit describes how an app process launched by the launcher has exited.
+type AppExit struct {
+	Id        string    `json:"id"`        // the app id
+	Pid       int       `json:"pid"`       // the process id
+	StartedAt time.Time `json:"startedAt"` // the time the process has been started
+	ExitedAt  time.Time `json:"exitedAt"`  // the time the process has exited
+	ExitCode  int       `json:"exitCode"`  // the exit code, -1 if the process has been terminated by a signal
+	Signal    string    `json:"signal"`    // the signal terminating the process, empty if the process has exited by itself
+	Stopped   bool      `json:"stopped"`   // has the process been stopped by the launcher
+}
@@ -0,0 +1,12 @@
+package model
+
+// ReleaseHistoryEntry is a launcher or app release shown in the release history.
+type ReleaseHistoryEntry struct {
+	Id             string `json:"id"`             // the release id
+	Name           string `json:"name"`           // the release name
+	Description    string `json:"description"`    // the release description, used as the release notes in the changelog
+	Version        string `json:"version"`        // the release version
+	CodeVersion    string `json:"codeVersion"`    // the version of the release binaries
+	ContentVersion string `json:"contentVersion"` // the version of the release content
+	Installed      bool   `json:"installed"`      // is this release currently installed
+}
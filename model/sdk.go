@@ -0,0 +1,9 @@
+package model
+
+// SdkInstallation is an SDK release installed into the shared SDK directory and used by one or more apps.
+type SdkInstallation struct {
+	Id      string   `json:"id"`      // the SDK id
+	Version string   `json:"version"` // the installed SDK release version
+	Path    string   `json:"path"`    // the SDK installation directory
+	Apps    []string `json:"apps"`    // ids of the apps referencing this SDK release
+}
@@ -0,0 +1,41 @@
+package model
+
+// Settings are the launcher settings stored in the launcher config directory.
+type Settings struct {
+	PeerCache      PeerCacheSettings      `json:"peerCache"`      // LAN peer cache settings
+	DeepLinks      DeepLinkSettings       `json:"deepLinks"`      // deep link policy settings
+	Apps           map[string]AppSettings `json:"apps"`           // per app settings indexed by the app id
+	LegacyGamePort bool                   `json:"legacyGamePort"` // does the launcher also listen on the fixed TCP game port for the game builds not reading the game endpoint from the environment
+}
+
+// DeepLinkSettings configure the policy applied to the deep links opened by the web pages.
+type DeepLinkSettings struct {
+	RequireSignature bool `json:"requireSignature"` // are the deep links without a valid backend signature rejected
+}
+
+// AppSettings are the launcher settings of an app.
+type AppSettings struct {
+	MultipleInstances bool            `json:"multipleInstances"` // does the app allow launching another instance while it is running
+	Profiles          []LaunchProfile `json:"profiles"`          // the named launch profiles of the app
+	DefaultProfile    string          `json:"defaultProfile"`    // the name of the profile used by LaunchApp, the app is launched without a profile if empty
+	AllowDeepLinks    bool            `json:"allowDeepLinks"`    // are all the deep link actions of the app performed without the user confirmation
+	AllowedDeepLinks  []string        `json:"allowedDeepLinks"`  // the deep link actions of the app confirmed by the user, performed without the confirmation afterwards
+}
+
+// LaunchProfile is a named set of the options the app is launched with, e.g. Unreal flags such as -windowed or -log.
+type LaunchProfile struct {
+	Name        string            `json:"name"`        // the unique profile name
+	Arguments   []string          `json:"arguments"`   // the extra command line arguments passed after the launcher arguments
+	Environment map[string]string `json:"environment"` // the environment variables overriding the launcher environment
+	WorkingDir  string            `json:"workingDir"`  // the working directory, relative to the app executable directory if not absolute, the executable directory is used if empty
+}
+
+// PeerCacheSettings configure the LAN peer cache sharing the downloaded release files between the launchers on the local network.
+type PeerCacheSettings struct {
+	Enabled       bool     `json:"enabled"`       // is the peer cache enabled, the launcher neither serves nor requests the files from peers if disabled
+	DiscoveryPort int      `json:"discoveryPort"` // the UDP port listening for the peer queries
+	HttpPort      int      `json:"httpPort"`      // the TCP port serving the cached files, a random port is used if zero
+	PeerPorts     []int    `json:"peerPorts"`     // the UDP ports the queries are sent to, the discovery port is used if empty, several ports allow running several launchers on the same machine
+	Addresses     []string `json:"addresses"`     // the addresses the queries are sent to, the broadcast address is used if empty
+	MaxSize       uint64   `json:"maxSize"`       // the maximum size of the release archives kept for the peers, the installed files are shared in place and are not counted
+}
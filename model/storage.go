@@ -0,0 +1,44 @@
+package model
+
+// StorageReport describes the disk space used by the launcher.
+type StorageReport struct {
+	Apps             []AppStorage  `json:"apps"`             // the installed apps
+	CacheSize        uint64        `json:"cacheSize"`        // the size of the metadata and API response cache
+	PeerCacheSize    uint64        `json:"peerCacheSize"`    // the size of the release archives kept for the LAN peers
+	SdkSize          uint64        `json:"sdkSize"`          // the size of the shared SDK directory
+	TempSize         uint64        `json:"tempSize"`         // the size of the temporary data, including the partial downloads
+	Orphans          []StorageItem `json:"orphans"`          // the app directories in the libraries which are not registered as app installations
+	PartialDownloads []StorageItem `json:"partialDownloads"` // the leftovers of the interrupted downloads
+	Logs             []StorageItem `json:"logs"`             // the stale updater log files
+}
+
+// AppStorage is the disk space used by an installed app.
+type AppStorage struct {
+	Id   string `json:"id"`   // the app id
+	Name string `json:"name"` // the app name, empty if the app metadata is not available
+	Path string `json:"path"` // the app installation directory
+	Size uint64 `json:"size"` // the total size of the app files
+}
+
+// StorageItem is a file or directory which may be removed to reclaim disk space.
+type StorageItem struct {
+	Path  string `json:"path"`  // the path of the file or directory
+	Size  uint64 `json:"size"`  // the total size of the files
+	AppId string `json:"appId"` // the id of the app the item belongs to, empty if unknown
+}
+
+// CleanupOptions select the data removed by the storage cleanup.
+type CleanupOptions struct {
+	PartialDownloads bool     `json:"partialDownloads"` // remove the temporary data and the leftovers of the interrupted downloads
+	Orphans          []string `json:"orphans"`          // the paths of the orphaned app directories from the storage report confirmed by the user to be removed
+	Logs             bool     `json:"logs"`             // remove the stale updater log files
+	Cache            bool     `json:"cache"`            // remove the API response cache, the metadata cache used offline is kept
+	DryRun           bool     `json:"dryRun"`           // only report the items which would be removed
+}
+
+// CleanupResult describes the items removed by the storage cleanup.
+type CleanupResult struct {
+	Removed   []StorageItem `json:"removed"`   // the removed items, or the items which would be removed by a dry run
+	Failed    []StorageItem `json:"failed"`    // the items which failed to be removed
+	Reclaimed uint64        `json:"reclaimed"` // the reclaimed disk space
+}
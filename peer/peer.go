@@ -0,0 +1,473 @@
+// Package peer provides the LAN peer cache, the launchers on the local network share the downloaded release files instead of downloading them from the CDN again.
+// The launchers discover the files by sending UDP queries with the file hash to the broadcast address, the peers having the file reply with the URL serving it over HTTP.
+// The downloaded files must be verified against the hash before use, the peers are not trusted.
+package peer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"games.launch.launcher/model"
+	"games.launch.launcher/utils"
+	"github.com/gofrs/uuid"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ll "games.launch.launcher/logger"
+)
+
+// DefaultQueryTimeout is the time to wait for the peer offers.
+const DefaultQueryTimeout = 500 * time.Millisecond
+
+// indexFile is the file in the cache directory listing the shared files.
+const indexFile = "index.json"
+
+// maxMessageSize is the maximum size of the discovery message.
+const maxMessageSize = 1024
+
+// Discovery message types.
+const (
+	messageQuery = "query" // request for the file with the hash
+	messageOffer = "offer" // reply of the peer having the file
+)
+
+// Offer is a peer offering the file with the requested hash.
+type Offer struct {
+	Peer string `json:"peer"` // the id of the offering peer
+	Hash string `json:"hash"` // the hash of the file
+	Url  string `json:"url"`  // the URL serving the file
+	Size int64  `json:"size"` // the size of the file
+}
+
+// message is the discovery message sent over UDP.
+type message struct {
+	Type  string `json:"type"`            // the message type
+	Peer  string `json:"peer"`            // the id of the sending peer, used to ignore own queries
+	Hash  string `json:"hash"`            // the hash of the requested file
+	Offer *Offer `json:"offer,omitempty"` // the offer of the replying peer
+}
+
+// entry is a shared file.
+type entry struct {
+	Path    string    `json:"path"`    // the path of the shared file
+	Size    int64     `json:"size"`    // the size of the shared file
+	Kept    bool      `json:"kept"`    // is the file kept in the cache directory, the kept files are counted against the cache size limit
+	AddedAt time.Time `json:"addedAt"` // the time the file has been added
+}
+
+// Cache shares the files with the peers and discovers the files of the peers.
+type Cache struct {
+	Dir      string                  // the directory keeping the shared release archives and the index
+	Settings model.PeerCacheSettings // the peer cache settings
+
+	id       string
+	mu       sync.RWMutex
+	entries  map[string]*entry
+	conn     *net.UDPConn
+	listener net.Listener
+	server   *http.Server
+}
+
+// New creates a new Cache keeping the files in the given directory.
+func New(dir string, settings model.PeerCacheSettings) *Cache {
+	return &Cache{
+		Dir:      dir,
+		Settings: settings,
+		id:       uuid.Must(uuid.NewV4()).String(),
+		entries:  make(map[string]*entry),
+	}
+}
+
+// normalizeHash returns the hash in the form used as the index key.
+func normalizeHash(hash string) string {
+	return strings.ToLower(strings.TrimSpace(hash))
+}
+
+// Start loads the index and starts answering the peer queries and serving the shared files.
+func (c *Cache) Start() error {
+	err := utils.ReadJSONFile(filepath.Join(c.Dir, indexFile), &c.entries)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		ll.Logger.Warning(fmt.Sprintf("failed to read peer cache index, starting empty: %v\n", err))
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*entry)
+	}
+
+	c.listener, err = net.Listen("tcp", ":"+strconv.Itoa(c.Settings.HttpPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for peer requests: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/", c.serveFile)
+	c.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := c.server.Serve(c.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ll.Logger.Error(fmt.Sprintf("peer cache server stopped: %v\n", err))
+		}
+	}()
+
+	c.conn, err = net.ListenUDP("udp4", &net.UDPAddr{Port: c.Settings.DiscoveryPort})
+	if err != nil {
+		_ = c.server.Close()
+		return fmt.Errorf("failed to listen for peer queries: %w", err)
+	}
+	go c.answerQueries()
+
+	return nil
+}
+
+// Stop stops answering the peer queries and serving the shared files.
+func (c *Cache) Stop() error {
+	var err error
+	if c.conn != nil {
+		err = c.conn.Close()
+	}
+	if c.server != nil {
+		if serverErr := c.server.Close(); serverErr != nil && err == nil {
+			err = serverErr
+		}
+	}
+	return err
+}
+
+// httpPort returns the port serving the shared files.
+func (c *Cache) httpPort() int {
+	return c.listener.Addr().(*net.TCPAddr).Port
+}
+
+// saveIndex saves the index of the shared files, the caller must hold the lock.
+func (c *Cache) saveIndex() error {
+	return utils.WriteJSONFile(filepath.Join(c.Dir, indexFile), c.entries, 0644)
+}
+
+// lookup returns the shared file with the given hash if it still exists and has not been modified since it has been added.
+func (c *Cache) lookup(hash string) (*entry, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[normalizeHash(hash)]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	fi, err := os.Stat(e.Path)
+	if err != nil || fi.Size() != e.Size {
+		c.mu.Lock()
+		delete(c.entries, normalizeHash(hash))
+		_ = c.saveIndex()
+		c.mu.Unlock()
+		return nil, false
+	}
+
+	return e, true
+}
+
+// Add shares the file at the given path in place, e.g. a file installed into the app directory.
+func (c *Cache) Add(hash string, path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat shared file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[normalizeHash(hash)] = &entry{Path: path, Size: fi.Size(), AddedAt: time.Now()}
+
+	return c.saveIndex()
+}
+
+// Keep moves the file into the cache directory and shares it, e.g. a release archive which is removed after the extraction otherwise.
+// The oldest kept files are removed when the kept files exceed the cache size limit.
+func (c *Cache) Keep(hash string, path string) error {
+	hash = normalizeHash(hash)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat shared file: %w", err)
+	}
+
+	if c.Settings.MaxSize > 0 && uint64(fi.Size()) > c.Settings.MaxSize {
+		return nil
+	}
+
+	err = os.MkdirAll(c.Dir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create peer cache directory: %w", err)
+	}
+
+	keptPath := filepath.Join(c.Dir, hash)
+	err = moveFile(path, keptPath)
+	if err != nil {
+		return fmt.Errorf("failed to move file into peer cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[hash] = &entry{Path: keptPath, Size: fi.Size(), Kept: true, AddedAt: time.Now()}
+	c.evict()
+
+	return c.saveIndex()
+}
+
+// moveFile moves the file, the file is copied and removed if it can not be renamed, e.g. if the library is on another drive than the peer cache.
+// The copy is written next to the destination and renamed, so a partial copy is never served.
+func moveFile(source string, destination string) error {
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	tempPath := destination + ".tmp"
+	dst, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err = os.Rename(tempPath, destination); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	// The source is closed before the removal, as an open file can not be removed on Windows. The copy is complete, so the
+	// leftover source is only reported, it is removed with the temporary download directory.
+	_ = src.Close()
+	if err = os.Remove(source); err != nil {
+		ll.Logger.Warning(fmt.Sprintf("failed to remove moved file: %v\n", err))
+	}
+
+	return nil
+}
+
+// evict removes the oldest kept files until the kept files fit the cache size limit, the caller must hold the lock.
+func (c *Cache) evict() {
+	if c.Settings.MaxSize == 0 {
+		return
+	}
+
+	var kept []string
+	var size uint64
+	for hash, e := range c.entries {
+		if e.Kept {
+			kept = append(kept, hash)
+			size += uint64(e.Size)
+		}
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return c.entries[kept[i]].AddedAt.Before(c.entries[kept[j]].AddedAt)
+	})
+
+	for _, hash := range kept {
+		if size <= c.Settings.MaxSize {
+			break
+		}
+
+		e := c.entries[hash]
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			ll.Logger.Warning(fmt.Sprintf("failed to remove peer cache file: %v\n", err))
+			continue
+		}
+		size -= uint64(e.Size)
+		delete(c.entries, hash)
+	}
+}
+
+// Size returns the total size of the files kept in the cache directory.
+func (c *Cache) Size() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var size uint64
+	for _, e := range c.entries {
+		if e.Kept {
+			size += uint64(e.Size)
+		}
+	}
+	return size
+}
+
+// serveFile serves the shared file with the hash from the request path, range requests are supported to resume the interrupted downloads.
+func (c *Cache) serveFile(w http.ResponseWriter, r *http.Request) {
+	hash := strings.TrimPrefix(r.URL.Path, "/files/")
+
+	e, ok := c.lookup(hash)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(e.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
+
+// answerQueries replies to the peer queries for the shared files until the connection is closed.
+func (c *Cache) answerQueries() {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := c.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				ll.Logger.Error(fmt.Sprintf("failed to read peer query: %v\n", err))
+			}
+			return
+		}
+
+		var query message
+		if err = json.Unmarshal(buf[:n], &query); err != nil || query.Type != messageQuery || query.Peer == c.id {
+			continue
+		}
+
+		e, ok := c.lookup(query.Hash)
+		if !ok {
+			continue
+		}
+
+		ip, err := localAddressTo(addr)
+		if err != nil {
+			ll.Logger.Warning(fmt.Sprintf("failed to get local address: %v\n", err))
+			continue
+		}
+
+		reply := message{
+			Type: messageOffer,
+			Peer: c.id,
+			Hash: query.Hash,
+			Offer: &Offer{
+				Peer: c.id,
+				Hash: normalizeHash(query.Hash),
+				Url:  fmt.Sprintf("http://%s/files/%s", net.JoinHostPort(ip.String(), strconv.Itoa(c.httpPort())), normalizeHash(query.Hash)),
+				Size: e.Size,
+			},
+		}
+
+		b, err := json.Marshal(reply)
+		if err != nil {
+			continue
+		}
+
+		if _, err = c.conn.WriteToUDP(b, addr); err != nil {
+			ll.Logger.Warning(fmt.Sprintf("failed to send peer offer: %v\n", err))
+		}
+	}
+}
+
+// localAddressTo returns the local address used to reach the given address, no packets are sent.
+func localAddressTo(addr *net.UDPAddr) (net.IP, error) {
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// Find queries the peers for the file with the given hash and returns the offers received before the timeout.
+func (c *Cache) Find(ctx context.Context, hash string, timeout time.Duration) ([]Offer, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open peer query connection: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	b, err := json.Marshal(message{Type: messageQuery, Peer: c.id, Hash: normalizeHash(hash)})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := c.Settings.Addresses
+	if len(addresses) == 0 {
+		addresses = []string{net.IPv4bcast.String()}
+	}
+	ports := c.Settings.PeerPorts
+	if len(ports) == 0 {
+		ports = []int{c.Settings.DiscoveryPort}
+	}
+
+	for _, address := range addresses {
+		for _, port := range ports {
+			addr, err := net.ResolveUDPAddr("udp4", net.JoinHostPort(address, strconv.Itoa(port)))
+			if err != nil {
+				ll.Logger.Warning(fmt.Sprintf("invalid peer address %s: %v\n", address, err))
+				continue
+			}
+			if _, err = conn.WriteToUDP(b, addr); err != nil {
+				ll.Logger.Warning(fmt.Sprintf("failed to send peer query to %s: %v\n", addr, err))
+			}
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	err = conn.SetReadDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	var offers []Offer
+	seen := make(map[string]bool)
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return offers, err
+		}
+
+		var reply message
+		if err = json.Unmarshal(buf[:n], &reply); err != nil || reply.Type != messageOffer || reply.Offer == nil || reply.Peer == c.id {
+			continue
+		}
+
+		if reply.Offer.Hash != normalizeHash(hash) || seen[reply.Offer.Url] {
+			continue
+		}
+		seen[reply.Offer.Url] = true
+
+		offers = append(offers, *reply.Offer)
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,136 @@
+// Package transport provides the local endpoints connecting the launcher processes and the game clients: a Unix domain socket in the user runtime directory
+// on Linux and macOS, a named pipe accessible by the user only on Windows, and a TCP port on the loopback interface as a fallback if the native endpoint
+// is not available. The dynamic TCP port is published in a port file, so the clients find it without a fixed port colliding with other software.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"games.launch.launcher/utils"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Address schemes, the address of an endpoint is the scheme followed by the socket path, the pipe name or the TCP address, e.g. unix:/run/user/1000/le7el-game.sock.
+const (
+	SchemeUnix = "unix"
+	SchemePipe = "pipe"
+	SchemeTcp  = "tcp"
+)
+
+var ErrorInUse = errors.New("endpoint is in use")
+var ErrorNotListening = errors.New("endpoint is not listening")
+
+// portFile keeps the port of the TCP fallback endpoint.
+type portFile struct {
+	Port int `json:"port"` // the TCP port on the loopback interface
+	Pid  int `json:"pid"`  // the PID of the listening process
+}
+
+// listener removes the port file of the TCP fallback endpoint when closed.
+type listener struct {
+	net.Listener
+	portPath string
+}
+
+// Close closes the listener and removes the port file.
+func (l *listener) Close() error {
+	err := l.Listener.Close()
+	if l.portPath != "" {
+		_ = os.Remove(l.portPath)
+	}
+	return err
+}
+
+// getPortPath returns the path of the port file of the endpoint in the directory.
+func getPortPath(dir string, name string) string {
+	return filepath.Join(dir, name+".port")
+}
+
+// Listen starts listening on the native endpoint with the given name, or on a dynamic TCP port published in the port file in the directory
+// if the native endpoint is not available. ErrorInUse is returned if another process is listening on the endpoint.
+// The returned address is passed to the clients which do not know the endpoint name, e.g. the game clients.
+func Listen(name string, dir string) (net.Listener, string, error) {
+	l, address, err := listenNative(name)
+	if err == nil {
+		return l, address, nil
+	}
+
+	if errors.Is(err, ErrorInUse) {
+		return nil, "", err
+	}
+
+	// Fall back to the TCP port, e.g. if the runtime directory is not available.
+	if conn, err := dialPort(name, dir, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, "", ErrorInUse
+	}
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on tcp port: %w", err)
+	}
+
+	port := tcp.Addr().(*net.TCPAddr).Port
+	portPath := getPortPath(dir, name)
+	err = utils.WriteJSONFile(portPath, portFile{Port: port, Pid: os.Getpid()}, 0600)
+	if err != nil {
+		_ = tcp.Close()
+		return nil, "", fmt.Errorf("failed to write port file: %w", err)
+	}
+
+	return &listener{Listener: tcp, portPath: portPath}, SchemeTcp + ":" + tcp.Addr().String(), nil
+}
+
+// Dial connects to the endpoint with the given name, the TCP port is looked up in the port file in the directory if the native endpoint is not listening.
+func Dial(name string, dir string, timeout time.Duration) (net.Conn, error) {
+	conn, err := dialNative(name, timeout)
+	if err == nil {
+		return conn, nil
+	}
+
+	conn, portErr := dialPort(name, dir, timeout)
+	if portErr == nil {
+		return conn, nil
+	}
+
+	return nil, fmt.Errorf("%w: %v, %v", ErrorNotListening, err, portErr)
+}
+
+// DialAddress connects to the endpoint address returned by Listen.
+func DialAddress(address string, timeout time.Duration) (net.Conn, error) {
+	scheme, path, ok := strings.Cut(address, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid endpoint address %q", address)
+	}
+
+	switch scheme {
+	case SchemeUnix:
+		return net.DialTimeout("unix", path, timeout)
+	case SchemePipe:
+		return dialPipe(path, timeout)
+	case SchemeTcp:
+		return net.DialTimeout("tcp", path, timeout)
+	default:
+		return nil, fmt.Errorf("unsupported endpoint address scheme %q", scheme)
+	}
+}
+
+// dialPort connects to the TCP port published in the port file.
+func dialPort(name string, dir string, timeout time.Duration) (net.Conn, error) {
+	var pf portFile
+	err := utils.ReadJSONFile(getPortPath(dir, name), &pf)
+	if err != nil {
+		return nil, err
+	}
+
+	if pf.Port <= 0 || pf.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d in port file", pf.Port)
+	}
+
+	return net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(pf.Port)), timeout)
+}
@@ -0,0 +1,97 @@
+//go:build !windows
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// getRuntimeDir returns the directory keeping the sockets, accessible by the user only.
+// The XDG runtime directory is used on Linux, a private directory in the temp directory is created otherwise, e.g. on macOS where the temp directory is per user.
+func getRuntimeDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("le7el-%d", os.Getuid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create runtime dir: %w", err)
+	}
+
+	// Another user may have created the directory in the shared temp directory to intercept the connections.
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat runtime dir: %w", err)
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !fi.IsDir() || fi.Mode().Perm() != 0700 || !ok || int(st.Uid) != os.Getuid() {
+		return "", fmt.Errorf("runtime dir %s is not private to the user", dir)
+	}
+
+	return dir, nil
+}
+
+// getSocketPath returns the path of the socket of the endpoint.
+func getSocketPath(name string) (string, error) {
+	dir, err := getRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "le7el-"+name+".sock"), nil
+}
+
+// listenNative listens on the Unix domain socket of the endpoint, the socket left by a crashed process is replaced.
+func listenNative(name string) (net.Listener, string, error) {
+	path, err := getSocketPath(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil && errors.Is(err, syscall.EADDRINUSE) {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, "", ErrorInUse
+		}
+
+		// Nobody listens on the socket, so it is stale.
+		if err = os.Remove(path); err != nil {
+			return nil, "", fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+
+		l, err = net.Listen("unix", path)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on socket: %w", err)
+	}
+
+	if err = os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, "", fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return l, SchemeUnix + ":" + path, nil
+}
+
+// dialNative connects to the Unix domain socket of the endpoint.
+func dialNative(name string, timeout time.Duration) (net.Conn, error) {
+	path, err := getSocketPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialTimeout("unix", path, timeout)
+}
+
+// dialPipe is not supported outside Windows.
+func dialPipe(string, time.Duration) (net.Conn, error) {
+	return nil, fmt.Errorf("named pipes are not supported on this platform")
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+	"net"
+	"os"
+	"time"
+)
+
+// pipeBufferSize is the size of the named pipe buffers.
+const pipeBufferSize = 64 << 10
+
+// getPipePath returns the name of the named pipe of the endpoint, the pipe name includes the user SID, so the pipes of the users signed in at the same time do not collide.
+func getPipePath(name string) (string, string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	sid := user.User.Sid.String()
+
+	return `\\.\pipe\le7el-` + name + "-" + sid, sid, nil
+}
+
+// listenNative listens on the named pipe of the endpoint accessible by the user only, the remote clients are rejected.
+func listenNative(name string) (net.Listener, string, error) {
+	path, sid, err := getPipePath(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	l, err := winio.ListenPipe(path, &winio.PipeConfig{
+		// Allow the full access to the current user only.
+		SecurityDescriptor: "D:P(A;;GA;;;" + sid + ")",
+		InputBufferSize:    pipeBufferSize,
+		OutputBufferSize:   pipeBufferSize,
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrExist) || errors.Is(err, windows.ERROR_ACCESS_DENIED) || errors.Is(err, windows.ERROR_PIPE_BUSY) {
+			return nil, "", ErrorInUse
+		}
+		return nil, "", fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	return l, SchemePipe + ":" + path, nil
+}
+
+// dialNative connects to the named pipe of the endpoint.
+func dialNative(name string, timeout time.Duration) (net.Conn, error) {
+	path, _, err := getPipePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return dialPipe(path, timeout)
+}
+
+// dialPipe connects to the named pipe, waiting for a free pipe instance until the timeout.
+func dialPipe(path string, timeout time.Duration) (net.Conn, error) {
+	return winio.DialPipe(path, &timeout)
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package utils
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// GetDiskSpace returns the free space available to the current user and the total space of the disk containing the given path.
+func GetDiskSpace(path string) (free uint64, total uint64, err error) {
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get disk free space: %w", err)
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), uint64(stat.Blocks) * uint64(stat.Bsize), nil
+}
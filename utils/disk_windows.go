@@ -0,0 +1,23 @@
+//go:build windows
+
+package utils
+
+import (
+	"fmt"
+	"golang.org/x/sys/windows"
+)
+
+// GetDiskSpace returns the free space available to the current user and the total space of the disk containing the given path.
+func GetDiskSpace(path string) (free uint64, total uint64, err error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to convert path: %w", err)
+	}
+
+	err = windows.GetDiskFreeSpaceEx(p, &free, &total, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get disk free space: %w", err)
+	}
+
+	return free, total, nil
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	ll "games.launch.launcher/logger"
+	"os"
+	"path/filepath"
+)
+
+// ReadJSONFile reads the JSON file at the given path and decodes it into the given value.
+func ReadJSONFile(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(b, v); err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to decode json file %s: %v\n", path, err))
+		return fmt.Errorf("failed to decode json file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// WriteJSONFile encodes the given value as JSON and writes it to the given path, the file is replaced atomically so readers never see a partially written file.
+func WriteJSONFile(path string, v any, perm os.FileMode) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to encode json file %s: %v\n", path, err))
+		return fmt.Errorf("failed to encode json file %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to create directory %s: %v\n", dir, err))
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, b, perm)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to write json file %s: %v\n", tmpPath, err))
+		return fmt.Errorf("failed to write json file %s: %w", tmpPath, err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		ll.Logger.Error(fmt.Sprintf("failed to replace json file %s: %v\n", path, err))
+		return fmt.Errorf("failed to replace json file %s: %w", path, err)
+	}
+
+	return nil
+}
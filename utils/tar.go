@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported archive formats.
+const (
+	archiveFormatZip = iota
+	archiveFormatTar
+	archiveFormatTarGz
+)
+
+// detectArchiveFormat detects the archive format by the file signature, the downloaded archives are named by the file id and have no extension.
+func detectArchiveFormat(archivePath string) (int, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	header := make([]byte, 262)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return archiveFormatTarGz, nil
+	case len(header) >= 262 && bytes.Equal(header[257:262], []byte("ustar")):
+		return archiveFormatTar, nil
+	default:
+		return archiveFormatZip, nil
+	}
+}
+
+// extractTarFiles extracts the given tar archive, optionally gzip compressed, to the given destination path except the skipped files and returns the paths of the extracted files relative to the destination path.
+func extractTarFiles(ctx context.Context, archivePath string, compressed bool, destinationPath string, skip []string) ([]string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		runtime.LogErrorf(ctx, "failed to open archive: %s", err)
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		if err1 := f.Close(); err1 != nil {
+			runtime.LogErrorf(ctx, "failed to close archive: %s", err1)
+		}
+	}()
+
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			runtime.LogErrorf(ctx, "failed to open compressed archive: %s", err)
+			return nil, fmt.Errorf("failed to open compressed archive: %w", err)
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+
+	err = os.MkdirAll(destinationPath, 0755)
+	if err != nil {
+		runtime.LogErrorf(ctx, "failed to create destination directory: %s", err)
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	var files []string
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			runtime.LogErrorf(ctx, "failed to read archive: %s", err)
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if isSkipped(header.Name, skip) {
+			continue
+		}
+
+		path := filepath.Join(destinationPath, header.Name)
+
+		// Archives created from the current directory contain the "./" root entry.
+		if path == filepath.Clean(destinationPath) {
+			continue
+		}
+
+		if !strings.HasPrefix(path, filepath.Clean(destinationPath)+string(os.PathSeparator)) {
+			runtime.LogErrorf(ctx, "illegal file path: %s", path)
+			return nil, fmt.Errorf("illegal file path: %s", path)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+			if err != nil {
+				runtime.LogErrorf(ctx, "failed to create directory: %s", err)
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err != nil {
+				runtime.LogErrorf(ctx, "failed to create directory: %s", err)
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+
+			err = writeFile(path, tr, header.FileInfo().Mode().Perm())
+			if err != nil {
+				runtime.LogErrorf(ctx, "failed to write file: %s", err)
+				return nil, fmt.Errorf("failed to write file: %w", err)
+			}
+
+			files = append(files, filepath.Clean(filepath.FromSlash(header.Name)))
+		default:
+			// Links and special files are not used by the app releases.
+			runtime.LogWarningf(ctx, "skipping unsupported archive entry: %s", header.Name)
+		}
+	}
+
+	return files, nil
+}
+
+// writeFile writes the contents of the reader to the file at the given path.
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	return f.Close()
+}
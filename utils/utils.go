@@ -8,16 +8,40 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
 // ExtractArchive extracts the given archive to the given destination path.
 func ExtractArchive(ctx context.Context, archivePath string, destinationPath string) error {
+	_, err := ExtractArchiveFiles(ctx, archivePath, destinationPath)
+	return err
+}
+
+// ExtractArchiveFiles extracts the given zip, tar or gzip compressed tar archive to the given destination path and returns the paths of the extracted files relative to the destination path.
+// The files with the given slash separated paths in the archive are skipped, e.g. the package metadata.
+func ExtractArchiveFiles(ctx context.Context, archivePath string, destinationPath string, skip ...string) ([]string, error) {
+	format, err := detectArchiveFormat(archivePath)
+	if err != nil {
+		runtime.LogErrorf(ctx, "failed to detect archive format: %s", err)
+		return nil, fmt.Errorf("failed to detect archive format: %w", err)
+	}
+
+	switch format {
+	case archiveFormatTar, archiveFormatTarGz:
+		return extractTarFiles(ctx, archivePath, format == archiveFormatTarGz, destinationPath, skip)
+	default:
+		return extractZipFiles(ctx, archivePath, destinationPath, skip)
+	}
+}
+
+// extractZipFiles extracts the given zip archive to the given destination path except the skipped files and returns the paths of the extracted files relative to the destination path.
+func extractZipFiles(ctx context.Context, archivePath string, destinationPath string, skip []string) ([]string, error) {
 	r, err := zip.OpenReader(archivePath)
 	if err != nil {
 		runtime.LogErrorf(ctx, "failed to open archive: %s", err)
-		return fmt.Errorf("failed to open archive: %w", err)
+		return nil, fmt.Errorf("failed to open archive: %w", err)
 	}
 
 	defer func(r *zip.ReadCloser) {
@@ -29,9 +53,11 @@ func ExtractArchive(ctx context.Context, archivePath string, destinationPath str
 	err = os.MkdirAll(destinationPath, 0755)
 	if err != nil {
 		runtime.LogErrorf(ctx, "failed to create destination directory: %s", err)
-		return fmt.Errorf("failed to create destination directory: %w", err)
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
+	var files []string
+
 	extractAndWriteFile := func(f *zip.File) error {
 		rc, err := f.Open()
 		if err != nil {
@@ -87,12 +113,52 @@ func ExtractArchive(ctx context.Context, archivePath string, destinationPath str
 	}
 
 	for _, f := range r.File {
+		if isSkipped(f.Name, skip) {
+			continue
+		}
+
 		err = extractAndWriteFile(f)
 		if err != nil {
 			runtime.LogErrorf(ctx, "failed to extract file: %s", err)
-			return fmt.Errorf("failed to extract file: %w", err)
+			return nil, fmt.Errorf("failed to extract file: %w", err)
+		}
+
+		if !f.FileInfo().IsDir() {
+			files = append(files, filepath.FromSlash(f.Name))
 		}
 	}
 
-	return nil
+	return files, nil
+}
+
+// ReadArchiveFile reads the file with the given slash separated path from the given zip archive, os.ErrNotExist is returned if the archive has no such file.
+func ReadArchiveFile(archivePath string, name string) ([]byte, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.ReadAll(f)
+}
+
+// isSkipped reports if the archive entry with the given name is one of the skipped slash separated paths.
+func isSkipped(name string, skip []string) bool {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	for _, s := range skip {
+		if name == path.Clean(s) {
+			return true
+		}
+	}
+	return false
 }
@@ -10,12 +10,33 @@ import (
 	"path/filepath"
 )
 
+// versionSize is the size of a single version in the .version file.
+const versionSize = 12
+
+// Record is the local version record of an installed release. The code and content versions are tracked independently, so content-only updates can keep the binaries untouched.
+// The .version file starts with the release version followed by the code and content versions, so the readers of the release version are not affected.
+type Record struct {
+	Version        *semver.Version // the release version
+	CodeVersion    *semver.Version // the version of the release binaries, nil if unknown
+	ContentVersion *semver.Version // the version of the release content, nil if unknown
+}
+
 // ReadVersion reads the version of a launcher or app release from the .version file in the given directory.
 func ReadVersion(dir string) (*semver.Version, error) {
+	record, err := ReadRecord(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Version, nil
+}
+
+// ReadRecord reads the version record of a launcher or app release from the .version file in the given directory.
+func ReadRecord(dir string) (*Record, error) {
 	versionFile := filepath.Join(dir, ".version")
 
 	if _, err := os.Stat(versionFile); os.IsNotExist(err) {
-		return &semver.Version{}, nil
+		return &Record{Version: &semver.Version{}}, nil
 	} else if err != nil {
 		ll.Logger.Error(fmt.Sprintf("failed to check if version file exists: %v\n", err))
 		return nil, fmt.Errorf("failed to check if version file exists: %w", err)
@@ -27,21 +48,44 @@ func ReadVersion(dir string) (*semver.Version, error) {
 		return nil, fmt.Errorf("failed to read version from file: %w", err)
 	}
 
-	versionMajor := binary.LittleEndian.Uint32(versionBytes[0:4])
-	versionMinor := binary.LittleEndian.Uint32(versionBytes[4:8])
-	versionPatch := binary.LittleEndian.Uint32(versionBytes[8:12])
+	if len(versionBytes) < versionSize {
+		ll.Logger.Error(fmt.Sprintf("invalid version file size: %d\n", len(versionBytes)))
+		return nil, fmt.Errorf("invalid version file size: %d", len(versionBytes))
+	}
+
+	record := &Record{}
 
-	version, err := semver.NewVersion(fmt.Sprintf("%d.%d.%d", versionMajor, versionMinor, versionPatch))
+	record.Version, err = decodeVersion(versionBytes[0:versionSize])
 	if err != nil {
 		ll.Logger.Error(fmt.Sprintf("failed to parse version: %v\n", err))
 		return nil, fmt.Errorf("failed to parse version: %w", err)
 	}
 
-	return version, nil
+	// Version files written before the code and content versions were tracked contain the release version only.
+	if len(versionBytes) >= versionSize*3 {
+		record.CodeVersion, err = decodeVersion(versionBytes[versionSize : versionSize*2])
+		if err != nil {
+			ll.Logger.Error(fmt.Sprintf("failed to parse code version: %v\n", err))
+			return nil, fmt.Errorf("failed to parse code version: %w", err)
+		}
+
+		record.ContentVersion, err = decodeVersion(versionBytes[versionSize*2 : versionSize*3])
+		if err != nil {
+			ll.Logger.Error(fmt.Sprintf("failed to parse content version: %v\n", err))
+			return nil, fmt.Errorf("failed to parse content version: %w", err)
+		}
+	}
+
+	return record, nil
 }
 
 // WriteVersion writes the version of a launcher or app release to the .version file in the given directory.
 func WriteVersion(dir string, version *semver.Version) error {
+	return WriteRecord(dir, &Record{Version: version})
+}
+
+// WriteRecord writes the version record of a launcher or app release to the .version file in the given directory, the code and content versions are written only if both are known.
+func WriteRecord(dir string, record *Record) error {
 	versionFile := filepath.Join(dir, ".version")
 
 	_, err := os.Stat(versionFile)
@@ -51,11 +95,12 @@ func WriteVersion(dir string, version *semver.Version) error {
 			return fmt.Errorf("failed to check if version file exists: %w", err)
 		}
 
-		_, err = os.Create(versionFile)
+		f, err := os.Create(versionFile)
 		if err != nil {
 			ll.Logger.Error(fmt.Sprintf("failed to create version file: %v\n", err))
 			return fmt.Errorf("failed to create version file: %w", err)
 		}
+		_ = f.Close()
 	} else {
 		err = os.Remove(versionFile)
 		if err != nil {
@@ -64,10 +109,11 @@ func WriteVersion(dir string, version *semver.Version) error {
 		}
 	}
 
-	versionBytes := make([]byte, 12)
-	binary.LittleEndian.PutUint32(versionBytes[0:4], uint32(version.Major()))
-	binary.LittleEndian.PutUint32(versionBytes[4:8], uint32(version.Minor()))
-	binary.LittleEndian.PutUint32(versionBytes[8:12], uint32(version.Patch()))
+	versionBytes := encodeVersion(record.Version)
+	if record.CodeVersion != nil && record.ContentVersion != nil {
+		versionBytes = append(versionBytes, encodeVersion(record.CodeVersion)...)
+		versionBytes = append(versionBytes, encodeVersion(record.ContentVersion)...)
+	}
 
 	err = os.WriteFile(versionFile, versionBytes, 0644)
 	if err != nil {
@@ -77,3 +123,21 @@ func WriteVersion(dir string, version *semver.Version) error {
 
 	return nil
 }
+
+// decodeVersion decodes the version stored as three little-endian uint32 numbers.
+func decodeVersion(b []byte) (*semver.Version, error) {
+	versionMajor := binary.LittleEndian.Uint32(b[0:4])
+	versionMinor := binary.LittleEndian.Uint32(b[4:8])
+	versionPatch := binary.LittleEndian.Uint32(b[8:12])
+
+	return semver.NewVersion(fmt.Sprintf("%d.%d.%d", versionMajor, versionMinor, versionPatch))
+}
+
+// encodeVersion encodes the version as three little-endian uint32 numbers.
+func encodeVersion(version *semver.Version) []byte {
+	versionBytes := make([]byte, versionSize)
+	binary.LittleEndian.PutUint32(versionBytes[0:4], uint32(version.Major()))
+	binary.LittleEndian.PutUint32(versionBytes[4:8], uint32(version.Minor()))
+	binary.LittleEndian.PutUint32(versionBytes[8:12], uint32(version.Patch()))
+	return versionBytes
+}